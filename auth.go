@@ -0,0 +1,51 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithBearerToken sets an Authorization: Bearer header with token on
+// every request.
+// An Authorization header set on a Request takes precedence.
+// Only one of WithBearerToken, WithBearerTokenFunc and WithBasicAuth
+// applies: the last one passed to NewClient wins.
+func WithBearerToken(token string) ClientOption {
+	return WithBearerTokenFunc(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithBearerTokenFunc calls fn before every request and sets an
+// Authorization: Bearer header with the token it returns, which allows
+// tokens to be refreshed without creating a new Client.
+// If fn returns an error, the request fails without being sent.
+// With WithResponseCache or WithSingleFlight, fn is also called to tell
+// the requests of different users apart, so it may be called twice for
+// a request.
+func WithBearerTokenFunc(fn func(ctx context.Context) (string, error)) ClientOption {
+	return func(client *Client) {
+		client.authorize = func(ctx context.Context, r *http.Request) error {
+			token, err := fn(ctx)
+			if err != nil {
+				return err
+			}
+			r.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	}
+}
+
+// WithBasicAuth sets an Authorization: Basic header with username and
+// password on every request, which is simpler than configuring a
+// transport for services behind a basic auth proxy.
+// An Authorization header set on a Request takes precedence, and the
+// last auth option passed to NewClient wins.
+func WithBasicAuth(username, password string) ClientOption {
+	return func(client *Client) {
+		client.authorize = func(ctx context.Context, r *http.Request) error {
+			r.SetBasicAuth(username, password)
+			return nil
+		}
+	}
+}
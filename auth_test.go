@@ -0,0 +1,125 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithBearerToken(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("Authorization"), "Bearer secret")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{
+		NewClient(WithBearerToken("secret")),
+		NewClient(WithBearerToken("secret"), UseMultipartForm()),
+	} {
+		err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+		is.NoErr(err)
+	}
+	is.Equal(calls, 2)
+}
+
+func TestWithBearerTokenFunc(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("Authorization"), "Bearer token-1")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var tokens int
+	client := NewClient(WithBearerTokenFunc(func(context.Context) (string, error) {
+		tokens++
+		if tokens > 1 {
+			return "", errors.New("token expired")
+		}
+		return "token-1", nil
+	}))
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+	err = client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.Equal(err.Error(), "authorize: token expired")
+	is.Equal(calls, 1) // no request is made without a token
+}
+
+func TestWithBasicAuth(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("Authorization"), "Basic dXNlcjpwYXNz")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{
+		NewClient(WithBasicAuth("user", "pass")),
+		NewClient(WithBasicAuth("user", "pass"), UseMultipartForm()),
+		NewClient(WithBearerToken("secret"), WithBasicAuth("user", "pass")),
+	} {
+		err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+		is.NoErr(err)
+	}
+	is.Equal(calls, 3)
+}
+
+func TestLastAuthOptionWins(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Authorization"), "Bearer secret")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithBasicAuth("user", "pass"), WithBearerToken("secret"))
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+}
+
+func TestCredentialsNotLogged(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Authorization"), "Bearer s3cret")
+		is.Equal(r.Header.Get("Cookie"), "session=c00kie")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithBearerToken("s3cret"))
+	var logs []string
+	client.Log = func(s string) { logs = append(logs, s) }
+	req := NewRequest("query {}", srv.URL)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "c00kie"})
+	is.NoErr(client.Run(ctx, req, nil))
+	output := strings.Join(logs, "\n")
+	is.True(strings.Contains(output, "Authorization:[***]")) // redacted
+	is.True(strings.Contains(output, "Cookie:[***]"))        // redacted
+	is.True(!strings.Contains(output, "s3cret"))
+	is.True(!strings.Contains(output, "c00kie"))
+}
@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RunBatch executes several requests in a single HTTP call by sending
+// them as a JSON array, and unmarshals the data field of each element of
+// the response array into the response object at the same index.
+// A nil response object skips parsing of that element.
+//
+// All requests must share the same endpoint, and their headers are
+// applied in order. Batching is not supported with the UseMultipartForm
+// option and batches are never retried. A batch with a mutation removes
+// the responses of its endpoint from the cache set with
+// WithResponseCache, like Run.
+// If any of the responses contains GraphQL errors, the first
+// *GraphQLError is returned once every response has been unmarshaled.
+func (c *Client) RunBatch(ctx context.Context, reqs []*Request, resps []interface{}) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	if c.useMultipartForm {
+		return errors.New("cannot batch requests with UseMultipartForm option")
+	}
+	if len(reqs) == 0 {
+		return errors.New("no requests to batch")
+	}
+	if len(reqs) != len(resps) {
+		return fmt.Errorf("graphql: %d requests but %d response objects", len(reqs), len(resps))
+	}
+	endpoint := c.endpointOf(reqs[0])
+	bodies := make([]jsonBody, len(reqs))
+	headers := make([]http.Header, len(reqs))
+	var invalidate bool
+	for i, req := range reqs {
+		req, err := c.prepare(req)
+		if err != nil {
+			return err
+		}
+		if len(req.files) > 0 {
+			return errors.New("cannot send files in a batch")
+		}
+		if req.Endpoint != endpoint {
+			return errors.New("cannot batch requests to different endpoints")
+		}
+		if selectedOperationType(req.q, req.operationName) != "query" {
+			invalidate = true
+		}
+		bodies[i] = newJSONBody(req)
+		headers[i] = req.Header
+		c.logf(">> batch %d variables: %v", i, c.redactVars(req))
+		c.logf(">> batch %d query: %s", i, req.q)
+	}
+	if invalidate && c.cache != nil {
+		defer c.cache.invalidate(endpoint)
+	}
+	var requestBody bytes.Buffer
+	if err := c.newEncoder(&requestBody).Encode(bodies); err != nil {
+		return errors.Wrap(err, "encode body")
+	}
+	if err := c.checkRequestSize(requestBody.Len()); err != nil {
+		return err
+	}
+	r, err := http.NewRequest(http.MethodPost, endpoint, &requestBody)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r, err = c.prepareRequest(ctx, r, headers...)
+	if err != nil {
+		return err
+	}
+	res, err := c.roundTrip(ctx, c.httpClient, r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	for _, req := range reqs {
+		req.captureHeader(res)
+	}
+	if err := c.afterReceive(ctx, res); err != nil {
+		return err
+	}
+	buf, err := readBody(res, c.maxResponseBytes)
+	if err != nil {
+		return err
+	}
+	c.logf("<< %s", buf.String())
+	if !isSuccess(res.StatusCode) {
+		return c.newHTTPError(res, buf.Bytes())
+	}
+	var results []json.RawMessage
+	if err := c.newDecoder(buf).Decode(&results); err != nil {
+		return &DecodeError{Err: err}
+	}
+	if len(results) != len(reqs) {
+		return fmt.Errorf("graphql: sent %d requests but received %d responses", len(reqs), len(results))
+	}
+	var gqlErr error
+	for i := range results {
+		err := c.decodeBody(results[i], reqs[i], resps[i])
+		if _, ok := err.(*GraphQLError); ok {
+			if gqlErr == nil {
+				gqlErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "batch response %d", i)
+		}
+	}
+	return gqlErr
+}
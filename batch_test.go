@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunBatch(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Method, http.MethodPost)
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		is.Equal(string(b), `[{"query":"query { a }","variables":{"id":1}},{"query":"query { b }","variables":null}]`+"\n")
+		io.WriteString(w, `[{"data":{"value":"a"}},{"data":{"value":"b"}}]`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	reqA := NewRequest("query { a }", srv.URL)
+	reqA.Var("id", 1)
+	reqB := NewRequest("query { b }", srv.URL)
+	var respA, respB struct {
+		Value string
+	}
+	err := client.RunBatch(ctx, []*Request{reqA, reqB}, []interface{}{&respA, &respB})
+	is.NoErr(err)
+	is.Equal(calls, 1)
+	is.Equal(respA.Value, "a")
+	is.Equal(respB.Value, "b")
+}
+
+func TestRunBatchLengthMismatch(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"data":{"value":"a"}}]`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	reqs := []*Request{NewRequest("query { a }", srv.URL), NewRequest("query { b }", srv.URL)}
+	err := client.RunBatch(ctx, reqs, []interface{}{nil, nil})
+	is.Equal(err.Error(), "graphql: sent 2 requests but received 1 responses")
+}
+
+func TestRunBatchMultipart(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(UseMultipartForm())
+	err := client.RunBatch(context.Background(), []*Request{NewRequest("query {}", "")}, []interface{}{nil})
+	is.Equal(err.Error(), "cannot batch requests with UseMultipartForm option")
+}
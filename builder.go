@@ -0,0 +1,245 @@
+package graphql
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Field is a field of a query assembled with Query or Mutation, for
+// selections that are only known at run time:
+//
+//	req := graphql.Query("user").Arg("id", 123).Select("name", "email").Build()
+//
+// builds a request for
+//
+//	query ($id: Int!) { user(id: $id) { name email } }
+//
+// with the variable id set to 123. Argument values are always sent as
+// variables, so they need no escaping. Field and argument names are
+// checked when the request is built, and Run returns an error for
+// invalid ones.
+type Field struct {
+	// op is the operation type of a root field.
+	op     string
+	name   string
+	alias  string
+	args   []argument
+	fields []*Field
+}
+
+// argument is an argument of a Field, sent as a variable of type typ.
+type argument struct {
+	name  string
+	typ   string
+	value interface{}
+}
+
+// Query starts a query with the root field name.
+func Query(name string) *Field {
+	return &Field{op: "query", name: name}
+}
+
+// Mutation starts a mutation with the root field name.
+func Mutation(name string) *Field {
+	return &Field{op: "mutation", name: name}
+}
+
+// NewField returns a field to be selected with SelectField.
+func NewField(name string) *Field {
+	return &Field{name: name}
+}
+
+// Alias sets the alias the field is returned under.
+func (f *Field) Alias(alias string) *Field {
+	f.alias = alias
+	return f
+}
+
+// Arg adds an argument to the field. Its GraphQL type is derived from
+// the Go type of value: strings, booleans, integers and floats map to
+// String!, Boolean!, Int! and Float!, slices to lists and pointers to
+// nullable types; []byte is sent as a base64 string of type String!.
+// Use ArgType for other types, such as ID! or input objects.
+func (f *Field) Arg(name string, value interface{}) *Field {
+	return f.ArgType(name, "", value)
+}
+
+// ArgType adds an argument of the GraphQL type typ to the field, such
+// as "ID!" or "UserInput!".
+func (f *Field) ArgType(name, typ string, value interface{}) *Field {
+	f.args = append(f.args, argument{name: name, typ: typ, value: value})
+	return f
+}
+
+// Select adds fields without arguments or selections of their own to
+// the selection of the field.
+func (f *Field) Select(names ...string) *Field {
+	for _, name := range names {
+		f.fields = append(f.fields, &Field{name: name})
+	}
+	return f
+}
+
+// SelectField adds fields built with NewField to the selection of the
+// field.
+func (f *Field) SelectField(fields ...*Field) *Field {
+	f.fields = append(f.fields, fields...)
+	return f
+}
+
+// Build returns a request for the operation with f as its root field,
+// sent to the endpoint set on the Client with WithEndpoint unless the
+// Endpoint of the request is set.
+func (f *Field) Build() *Request {
+	b := &queryBuilder{
+		vars:  make(map[string]interface{}),
+		names: make(map[string]bool),
+	}
+	var selection strings.Builder
+	b.writeField(&selection, f)
+	op := f.op
+	if op == "" {
+		op = "query"
+	}
+	q := op
+	if len(b.defs) > 0 {
+		q += " (" + strings.Join(b.defs, ", ") + ")"
+	}
+	req := NewRequest(q+" { "+selection.String()+" }", "")
+	req.err = b.err
+	for key, value := range b.vars {
+		req.Var(key, value)
+	}
+	return req
+}
+
+// queryBuilder collects the variables of a query while its fields are
+// written.
+type queryBuilder struct {
+	defs  []string
+	vars  map[string]interface{}
+	names map[string]bool
+	err   error
+}
+
+func (b *queryBuilder) writeField(w *strings.Builder, f *Field) {
+	b.checkName(f.name)
+	if f.alias != "" {
+		b.checkName(f.alias)
+		w.WriteString(f.alias + ": ")
+	}
+	w.WriteString(f.name)
+	if len(f.args) > 0 {
+		w.WriteByte('(')
+		for i, arg := range f.args {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			b.checkName(arg.name)
+			w.WriteString(arg.name + ": $" + b.addVar(arg))
+		}
+		w.WriteByte(')')
+	}
+	if len(f.fields) > 0 {
+		w.WriteString(" { ")
+		for i, field := range f.fields {
+			if i > 0 {
+				w.WriteByte(' ')
+			}
+			b.writeField(w, field)
+		}
+		w.WriteString(" }")
+	}
+}
+
+// addVar declares a variable for arg and returns its name, which is the
+// name of the argument unless another argument already uses it.
+func (b *queryBuilder) addVar(arg argument) string {
+	name := arg.name
+	for i := 2; b.names[name]; i++ {
+		name = arg.name + strconv.Itoa(i)
+	}
+	b.names[name] = true
+	typ := arg.typ
+	if typ != "" && !isName(strings.Trim(typ, "[]!")) && b.err == nil {
+		b.err = errors.Errorf("graphql: invalid type %q", typ)
+	}
+	if typ == "" {
+		var ok bool
+		if typ, ok = inferType(reflect.TypeOf(arg.value)); !ok && b.err == nil {
+			b.err = errors.Errorf("graphql: cannot infer the type of argument %q, use ArgType", arg.name)
+		}
+	}
+	b.defs = append(b.defs, "$"+name+": "+typ)
+	b.vars[name] = arg.value
+	return name
+}
+
+// checkName records an error if name is not a valid GraphQL name.
+func (b *queryBuilder) checkName(name string) {
+	if b.err != nil {
+		return
+	}
+	if name == "" {
+		b.err = errors.New("graphql: empty name")
+		return
+	}
+	if !isName(name) {
+		b.err = errors.Errorf("graphql: invalid name %q", name)
+	}
+}
+
+// isName reports whether name is a valid GraphQL name.
+func isName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !(i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// inferType returns the GraphQL type of values of the Go type t.
+func inferType(t reflect.Type) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		nullable = true
+		t = t.Elem()
+	}
+	var typ string
+	switch t.Kind() {
+	case reflect.String:
+		typ = "String"
+	case reflect.Bool:
+		typ = "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typ = "Int"
+	case reflect.Float32, reflect.Float64:
+		typ = "Float"
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json sends []byte as a base64 string
+			typ = "String"
+			break
+		}
+		elem, ok := inferType(t.Elem())
+		if !ok {
+			return "", false
+		}
+		typ = "[" + elem + "]"
+	default:
+		return "", false
+	}
+	if !nullable {
+		typ += "!"
+	}
+	return typ, true
+}
@@ -0,0 +1,54 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	is := is.New(t)
+	req := Query("user").Arg("id", 123).Select("name", "email").Build()
+	is.Equal(req.Query(), "query ($id: Int!) { user(id: $id) { name email } }")
+	is.Equal(req.Vars(), map[string]interface{}{"id": 123})
+	is.Equal(req.Endpoint, "")
+
+	first := 10
+	req = Query("user").ArgType("id", "ID!", "u1").Select("name").SelectField(
+		NewField("friends").Arg("first", &first).Select("name"),
+		NewField("friends").Alias("closeFriends").Arg("first", 3).Arg("tags", []string{"close"}).Select("name"),
+	).Build()
+	is.Equal(req.Query(), "query ($id: ID!, $first: Int, $first2: Int!, $tags: [String!]!) { user(id: $id) { name friends(first: $first) { name } closeFriends: friends(first: $first2, tags: $tags) { name } } }")
+	is.Equal(req.Vars(), map[string]interface{}{"id": "u1", "first": &first, "first2": 3, "tags": []string{"close"}})
+	is.Equal(operationType(req.Query()), "query")
+
+	req = Mutation("createUser").ArgType("input", "UserInput!", map[string]interface{}{"name": "Mat \"the\" dev"}).Select("id").Build()
+	is.Equal(req.Query(), "mutation ($input: UserInput!) { createUser(input: $input) { id } }")
+	is.NoErr(req.check())
+
+	is.Equal(Query("viewer").Select("login").Build().Query(), "query { viewer { login } }")
+
+	req = Mutation("upload").Arg("data", []byte("hi")).Arg("chunks", [][]byte{}).Build()
+	is.Equal(req.Query(), "mutation ($data: String!, $chunks: [String!]!) { upload(data: $data, chunks: $chunks) }")
+}
+
+func TestQueryBuilderErrors(t *testing.T) {
+	is := is.New(t)
+	for _, tt := range []struct {
+		field *Field
+		err   string
+	}{
+		{field: Query("user { admin }"), err: `graphql: invalid name "user { admin }"`},
+		{field: Query("user").Select("name", "1st"), err: `graphql: invalid name "1st"`},
+		{field: Query("user").Arg("id) { admin } #", 1), err: `graphql: invalid name "id) { admin } #"`},
+		{field: Query("user").Arg("filter", struct{}{}), err: `graphql: cannot infer the type of argument "filter", use ArgType`},
+		{field: Query("user").Arg("id", nil), err: `graphql: cannot infer the type of argument "id", use ArgType`},
+		{field: Query(""), err: "graphql: empty name"},
+		{field: Query("user").ArgType("id", "ID!) { admin } query q($x: ID!", 1), err: `graphql: invalid type "ID!) { admin } query q($x: ID!"`},
+		{field: Query("user").ArgType("id", "[]", 1), err: `graphql: invalid type "[]"`},
+	} {
+		err := tt.field.Build().check()
+		is.True(err != nil)
+		is.Equal(err.Error(), tt.err)
+	}
+}
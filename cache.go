@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WithResponseCache caches the data of successful query responses in
+// memory for ttl, so identical queries made within ttl return the
+// cached data without a request to the server. Queries are identical
+// when they have the same endpoint, query, operation name, variables and
+// headers, including the credentials set by the authentication options,
+// the headers set with WithContextHeader and the cookies of the cookie
+// jar, so responses are never shared between users. At most maxEntries
+// responses are kept, dropping the least recently used ones first.
+// Mutations are never cached, and running a mutation removes the cached
+// responses of its endpoint. Requests with files are never cached, and
+// the extensions of cached responses are not unmarshaled.
+func WithResponseCache(ttl time.Duration, maxEntries int) ClientOption {
+	return func(client *Client) {
+		client.cache = &responseCache{
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			entries:    make(map[string]*list.Element),
+			order:      list.New(),
+			now:        time.Now,
+		}
+	}
+}
+
+// responseCache is a least recently used cache of response data.
+type responseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries, most recently used first.
+	order *list.List
+}
+
+type cacheEntry struct {
+	key      string
+	endpoint string
+	data     json.RawMessage
+	expires  time.Time
+}
+
+// get returns the data cached for key, if it has not expired.
+func (c *responseCache) get(key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.data, true
+}
+
+// add caches data for key, evicting the least recently used entry if
+// the cache is full.
+func (c *responseCache) add(key, endpoint string, data json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{
+		key:      key,
+		endpoint: endpoint,
+		data:     data,
+		expires:  c.now().Add(c.ttl),
+	})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate removes the entries cached for endpoint.
+func (c *responseCache) invalidate(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*cacheEntry).endpoint == endpoint {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+func (c *responseCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
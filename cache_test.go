@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithResponseCache(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithResponseCache(time.Minute, 10))
+	now := time.Now()
+	client.cache.now = func() time.Time { return now }
+	run := func(q string) string {
+		var resp struct {
+			Value string
+		}
+		err := client.Run(ctx, NewRequest(q, srv.URL), &resp)
+		is.NoErr(err)
+		return resp.Value
+	}
+
+	is.Equal(run("query { value }"), "some data")
+	is.Equal(calls, 1)
+	is.Equal(run("query { value }"), "some data")
+	is.Equal(calls, 1) // hit
+	is.Equal(run("{ value }"), "some data")
+	is.Equal(calls, 2) // different query
+
+	now = now.Add(time.Minute)
+	is.Equal(run("query { value }"), "some data")
+	is.Equal(calls, 3) // expired
+	is.Equal(run("query { value }"), "some data")
+	is.Equal(calls, 3) // hit
+
+	is.Equal(run("mutation { value }"), "some data")
+	is.Equal(calls, 4)
+	is.Equal(run("mutation { value }"), "some data")
+	is.Equal(calls, 5) // mutations are not cached
+	is.Equal(run("query { value }"), "some data")
+	is.Equal(calls, 6) // the mutation invalidated the cache
+}
+
+func TestWithResponseCacheErrors(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{"value":"partial"},"errors":[{"message":"miss"}]}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithResponseCache(time.Minute, 10))
+	for i := 0; i < 2; i++ {
+		var resp struct {
+			Value string
+		}
+		err := client.Run(ctx, NewRequest("query { value }", srv.URL), &resp)
+		is.Equal(err.Error(), "graphql: miss")
+		is.Equal(resp.Value, "partial")
+	}
+	is.Equal(calls, 2) // responses with errors are not cached
+}
+
+func TestWithResponseCacheBatchMutation(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		if bytes.HasPrefix(b, []byte("[")) {
+			io.WriteString(w, `[{"data":{}},{"data":{}}]`)
+			return
+		}
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithResponseCache(time.Minute, 10))
+	run := func() {
+		is.NoErr(client.Run(ctx, NewRequest("query { value }", srv.URL), nil))
+	}
+	batch := func(q string) {
+		reqs := []*Request{NewRequest("query { other }", srv.URL), NewRequest(q, srv.URL)}
+		is.NoErr(client.RunBatch(ctx, reqs, []interface{}{nil, nil}))
+	}
+
+	run()
+	batch("query { another }")
+	run()
+	is.Equal(calls, 2) // a batch of queries keeps the cache
+	batch("mutation { set }")
+	run()
+	is.Equal(calls, 4) // a batch with a mutation invalidated the cache
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(WithResponseCache(time.Minute, 2))
+	cache := client.cache
+	cache.add("a", "http://example.com", []byte(`1`))
+	cache.add("b", "http://example.com", []byte(`2`))
+	_, ok := cache.get("a")
+	is.True(ok)
+	cache.add("c", "http://other.com", []byte(`3`))
+	_, ok = cache.get("b")
+	is.True(!ok) // least recently used is evicted
+	_, ok = cache.get("a")
+	is.True(ok)
+
+	cache.invalidate("http://example.com")
+	_, ok = cache.get("a")
+	is.True(!ok)
+	_, ok = cache.get("c")
+	is.True(ok)
+}
+
+func TestWithResponseCachePerUser(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{"me":"`+r.Header.Get("Authorization")+`"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	type tokenKey struct{}
+	client := NewClient(WithResponseCache(time.Minute, 10), WithBearerTokenFunc(func(ctx context.Context) (string, error) {
+		return ctx.Value(tokenKey{}).(string), nil
+	}))
+	me := func(token string) string {
+		var resp struct {
+			Me string
+		}
+		err := client.Run(context.WithValue(ctx, tokenKey{}, token), NewRequest("query { me }", srv.URL), &resp)
+		is.NoErr(err)
+		return resp.Me
+	}
+
+	is.Equal(me("alice"), "Bearer alice")
+	is.Equal(me("bob"), "Bearer bob") // not alice's cached response
+	is.Equal(calls, 2)
+	is.Equal(me("alice"), "Bearer alice")
+	is.Equal(calls, 2) // hit
+}
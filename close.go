@@ -0,0 +1,70 @@
+package graphql
+
+import "github.com/pkg/errors"
+
+// ErrClientClosed is returned when a Client is used after Close.
+var ErrClientClosed = errors.New("graphql: client is closed")
+
+// Close releases the resources held by the Client: it ends all running
+// subscriptions and closes the idle connections of the transport the
+// Client created for options such as WithConnectionPool.
+//
+// Close does not close the idle connections of a transport the Client
+// shares with other code, which is the case for a Client created without
+// such options, using http.DefaultTransport, and for the http.Client set
+// with WithHTTPClient. With a default Client, Close therefore frees no
+// connections; call CloseIdleConnections on the http.Client or transport
+// that owns them instead, such as http.DefaultClient, once no other code
+// uses it.
+//
+// The Client cannot be used afterwards; Run and the other methods
+// return ErrClientClosed. It is safe to call Close more than once.
+func (c *Client) Close() error {
+	c.closeMu.Lock()
+	if c.closed {
+		c.closeMu.Unlock()
+		return nil
+	}
+	c.closed = true
+	subs := c.subs
+	c.subs = nil
+	c.closeMu.Unlock()
+	for s := range subs {
+		s.Close()
+	}
+	if c.ownTransport != nil {
+		c.ownTransport.CloseIdleConnections()
+	}
+	return nil
+}
+
+// idleCloser is implemented by transports that keep idle connections.
+type idleCloser interface {
+	CloseIdleConnections()
+}
+
+// track adds s to the subscriptions ended by Close. It reports false if
+// the Client is already closed.
+func (c *Client) track(s *Subscription) bool {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closed {
+		return false
+	}
+	if c.subs == nil {
+		c.subs = make(map[*Subscription]struct{})
+	}
+	c.subs[s] = struct{}{}
+	s.untrack = func() {
+		c.closeMu.Lock()
+		delete(c.subs, s)
+		c.closeMu.Unlock()
+	}
+	return true
+}
+
+func (c *Client) isClosed() bool {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	return c.closed
+}
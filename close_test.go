@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type closeIdleSpy struct {
+	closeIdle int
+}
+
+func (s *closeIdleSpy) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"data":{}}`)),
+	}, nil
+}
+
+func (s *closeIdleSpy) CloseIdleConnections() {
+	s.closeIdle++
+}
+
+func TestClose(t *testing.T) {
+	is := is.New(t)
+	spy := &closeIdleSpy{}
+	client := NewClient(WithHTTPClient(&http.Client{Transport: spy}))
+	ctx := context.Background()
+	is.NoErr(client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), nil))
+
+	is.NoErr(client.Close())
+	is.NoErr(client.Close())   // closing twice is fine
+	is.Equal(spy.closeIdle, 0) // the transport of the given client is left alone
+
+	err := client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), nil)
+	is.Equal(err, ErrClientClosed)
+}
+
+func TestCloseIdleConnections(t *testing.T) {
+	is := is.New(t)
+	closed := make(chan struct{}, 10)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), nil))
+	is.NoErr(client.Close())
+	select {
+	case <-closed:
+		is.Fail() // the connection of the shared default transport was closed
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	client = NewClient(WithConnectionPool(10, 10, 0))
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), nil))
+	is.NoErr(client.Close())
+	select {
+	case <-closed: // the connection of the cloned transport was closed
+	case <-ctx.Done():
+		is.Fail()
+	}
+}
@@ -0,0 +1,110 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Encoder encodes values to JSON. *json.Encoder implements Encoder.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder decodes JSON values. *json.Decoder implements Decoder.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// WithJSONEncoder sets the function used to create the Encoder that
+// serializes request bodies and variables in Run and RunBatch.
+// The default uses encoding/json.
+func WithJSONEncoder(fn func(w io.Writer) Encoder) ClientOption {
+	return func(client *Client) {
+		client.newEncoder = fn
+	}
+}
+
+// WithJSONDecoder sets the function used to create the Decoder that
+// parses responses in Run and RunBatch. The default uses encoding/json.
+// To decode numbers as json.Number use:
+//
+//	WithJSONDecoder(func(r io.Reader) graphql.Decoder {
+//		dec := json.NewDecoder(r)
+//		dec.UseNumber()
+//		return dec
+//	})
+func WithJSONDecoder(fn func(r io.Reader) Decoder) ClientOption {
+	return func(client *Client) {
+		client.newDecoder = fn
+	}
+}
+
+// WithCompactJSON controls whether request bodies are encoded as
+// compact JSON, the default, or indented for readability, which helps
+// when inspecting requests in logs or snapshot tests of BuildRequest.
+// Both encode the same values. The option has no effect with
+// WithJSONEncoder.
+func WithCompactJSON(compact bool) ClientOption {
+	return func(client *Client) {
+		client.indentJSON = !compact
+	}
+}
+
+// WithCanonicalJSON makes the Client encode request bodies and
+// variables canonically: the keys of every JSON object are sorted,
+// including the fields of structs and of the request envelope itself,
+// so the same request always produces the same bytes, for example for
+// signing requests or for caches keyed by the body. Numbers are kept as
+// written by the encoder. It applies to the encoder set with
+// WithJSONEncoder as well, provided it sorts the keys of maps. The map
+// field of multipart requests keeps its keys in the order of the files.
+func WithCanonicalJSON() ClientOption {
+	return func(client *Client) {
+		client.canonicalJSON = true
+	}
+}
+
+// canonicalEncoder returns a function creating Encoders that encode
+// values with the Encoders created by newEncoder, with the keys of
+// every object sorted.
+func canonicalEncoder(newEncoder func(io.Writer) Encoder) func(io.Writer) Encoder {
+	return func(w io.Writer) Encoder {
+		return &canonicalJSONEncoder{w: w, newEncoder: newEncoder}
+	}
+}
+
+type canonicalJSONEncoder struct {
+	w          io.Writer
+	newEncoder func(io.Writer) Encoder
+}
+
+// Encode encodes v, decodes the JSON into maps, whose keys the encoder
+// sorts, and encodes it again.
+func (e *canonicalJSONEncoder) Encode(v interface{}) error {
+	var buf bytes.Buffer
+	if err := e.newEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	var generic interface{}
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	return e.newEncoder(e.w).Encode(generic)
+}
+
+func newJSONEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func newIndentedJSONEncoder(w io.Writer) Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc
+}
+
+func newJSONDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithJSONDecoder(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"id":9007199254740993}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithJSONDecoder(func(r io.Reader) Decoder {
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		return dec
+	}))
+	var resp map[string]interface{}
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), &resp)
+	is.NoErr(err)
+	is.Equal(resp["id"], json.Number("9007199254740993"))
+}
+
+func TestWithJSONEncoder(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal(body.Query, "query { a < b }")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var calls int
+	client := NewClient(WithJSONEncoder(func(w io.Writer) Encoder {
+		calls++
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc
+	}))
+	err := client.Run(ctx, NewRequest("query { a < b }", srv.URL), nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestWithCompactJSON(t *testing.T) {
+	is := is.New(t)
+	bodies := make(map[bool][]byte)
+	for _, compact := range []bool{true, false} {
+		client := NewClient(WithCompactJSON(compact))
+		req := NewRequest("query ($id: ID!) { user(id: $id) { name } }", "https://example.com/graphql")
+		req.Var("id", "1")
+		req.Var("filter", map[string]interface{}{"active": true, "tags": []string{"a", "b"}})
+		r, err := client.BuildRequest(context.Background(), req)
+		is.NoErr(err)
+		bodies[compact], err = ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+	}
+	is.True(len(bodies[false]) > len(bodies[true])) // indented
+	is.True(bytes.Contains(bodies[false], []byte("\n  \"variables\": {\n")))
+	is.Equal(bytes.Count(bodies[true], []byte("\n")), 1) // compact
+
+	var compact, indented interface{}
+	is.NoErr(json.Unmarshal(bodies[true], &compact))
+	is.NoErr(json.Unmarshal(bodies[false], &indented))
+	is.Equal(compact, indented) // the same values
+}
+
+func TestWithCanonicalJSON(t *testing.T) {
+	is := is.New(t)
+	type Filter struct {
+		Tags   []string `json:"tags"`
+		Active bool     `json:"active"`
+		Limit  int64    `json:"limit"`
+	}
+	client := NewClient(WithCanonicalJSON())
+	var bodies [][]byte
+	for i := 0; i < 3; i++ {
+		req := NewRequest("query ($id: ID!) { user(id: $id) { name } }", "https://example.com/graphql")
+		req.Var("id", "1")
+		req.Var("filter", Filter{Tags: []string{"b", "a"}, Active: true, Limit: 9007199254740993})
+		r, err := client.BuildRequest(context.Background(), req)
+		is.NoErr(err)
+		body, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		bodies = append(bodies, body)
+	}
+	is.Equal(string(bodies[0]), `{"query":"query ($id: ID!) { user(id: $id) { name } }","variables":{"filter":{"active":true,"limit":9007199254740993,"tags":["b","a"]},"id":"1"}}`+"\n")
+	is.Equal(bodies[1], bodies[0]) // byte-identical
+	is.Equal(bodies[2], bodies[0])
+}
+
+func TestWithCanonicalJSONMultipart(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(UseMultipartForm(), WithCanonicalJSON())
+	req := NewRequest("mutation ($files: [Upload!]!) { upload(files: $files) }", "https://example.com/graphql")
+	req.Var("z", 1)
+	var want []string
+	for i := 0; i < 11; i++ {
+		req.AppendFileVar("variables.files", fmt.Sprintf("%d.txt", i), strings.NewReader("contents"))
+		want = append(want, fmt.Sprintf(`"%d":["variables.files.%d"]`, i, i))
+	}
+	r, err := client.BuildRequest(context.Background(), req)
+	is.NoErr(err)
+	is.NoErr(r.ParseMultipartForm(1 << 20))
+	is.Equal(r.FormValue("operations"), `{"query":"mutation ($files: [Upload!]!) { upload(files: $files) }","variables":{"files":[null,null,null,null,null,null,null,null,null,null,null],"z":1}}`+"\n")
+	is.Equal(r.FormValue("map"), "{"+strings.Join(want, ",")+"}\n") // "10" follows "9"
+}
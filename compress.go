@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WithRequestCompression gzip-compresses JSON request bodies of at
+// least minBytes bytes and sets the Content-Encoding header accordingly.
+// Small bodies are not worth compressing, so pick a threshold of at
+// least a few kilobytes. Multipart requests are never compressed.
+// The server must support gzip-encoded requests.
+func WithRequestCompression(minBytes int) ClientOption {
+	return func(client *Client) {
+		client.compressMinBytes = minBytes
+	}
+}
+
+func gzipBytes(b []byte) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, errors.Wrap(err, "compress body")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "compress body")
+	}
+	return &buf, nil
+}
+
+// readBody reads the body of res, decompressing it if the server sent
+// it with a gzip or deflate Content-Encoding that the transport did not
+// already remove. If maxBytes is positive, ErrResponseTooLarge is
+// returned for bodies larger than maxBytes once decompressed.
+func readBody(res *http.Response, maxBytes int64) (*bytes.Buffer, error) {
+	body, err := uncompressedBody(res)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, limitResponse(body, maxBytes)); err != nil {
+		if err == ErrResponseTooLarge {
+			return nil, err
+		}
+		if body != res.Body {
+			return nil, &DecodeError{Err: err}
+		}
+		return nil, &NetworkError{Err: errors.Wrap(err, "reading body")}
+	}
+	return &buf, nil
+}
+
+// uncompressedBody returns a reader for the body of res that
+// decompresses it if needed, see readBody. The reader must be closed,
+// and may be the body of res itself.
+func uncompressedBody(res *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, &DecodeError{Err: err}
+		}
+		return zr, nil
+	case "deflate":
+		zr, err := zlib.NewReader(res.Body)
+		if err != nil {
+			return nil, &DecodeError{Err: err}
+		}
+		return zr, nil
+	}
+	return res.Body, nil
+}
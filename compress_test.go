@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithRequestCompression(t *testing.T) {
+	is := is.New(t)
+	var encodings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			is.NoErr(err)
+			defer zr.Close()
+			body = zr
+		}
+		var req struct {
+			Query     string
+			Variables map[string]string
+		}
+		is.NoErr(json.NewDecoder(body).Decode(&req))
+		is.Equal(req.Query, "mutation ($input: String!) { save(input: $input) }")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithRequestCompression(1024))
+	for _, input := range []string{"small", strings.Repeat("large", 1024)} {
+		req := NewRequest("mutation ($input: String!) { save(input: $input) }", srv.URL)
+		req.Var("input", input)
+		is.NoErr(client.Run(ctx, req, nil))
+	}
+	is.Equal(encodings, []string{"", "gzip"})
+}
+
+func TestGzipResponse(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, `{"data":{"x":1}}`)
+		zw.Close()
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	req := NewRequest("query { x }", srv.URL)
+	// setting Accept-Encoding stops the transport from decompressing
+	req.Header.Set("Accept-Encoding", "gzip")
+	var resp struct {
+		X int
+	}
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(resp.X, 1)
+}
+
+func TestGzipResponseCorrupt(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		io.WriteString(w, `{"data":{"x":1}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	req := NewRequest("query { x }", srv.URL)
+	req.Header.Set("Accept-Encoding", "gzip")
+	err := client.Run(ctx, req, nil)
+	is.Equal(err.Error(), "decoding response: gzip: invalid header")
+}
@@ -0,0 +1,31 @@
+package graphql
+
+import "net/http"
+
+// WithCookieJar sets jar as the cookie jar of the http.Client used by
+// the Client, so cookies set by a response, such as the session cookie
+// of a login mutation, are sent with later requests.
+// The http.Client given to WithHTTPClient is copied, not modified.
+func WithCookieJar(jar http.CookieJar) ClientOption {
+	return func(client *Client) {
+		client.jar = jar
+	}
+}
+
+// withJar returns a copy of httpClient that uses the cookie jar set
+// with WithCookieJar.
+func (c *Client) withJar(httpClient *http.Client) *http.Client {
+	withJar := *httpClient
+	withJar.Jar = c.jar
+	return &withJar
+}
+
+// AddCookie adds a cookie to the Cookie header of the request. Cookies
+// from the cookie jar of the Client are sent as well.
+func (req *Request) AddCookie(cookie *http.Cookie) {
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	r := http.Request{Header: req.Header}
+	r.AddCookie(cookie)
+}
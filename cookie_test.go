@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithCookieJar(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			_, err := r.Cookie("session")
+			is.Equal(err, http.ErrNoCookie)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+		} else {
+			cookie, err := r.Cookie("session")
+			is.NoErr(err)
+			is.Equal(cookie.Value, "abc123")
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	jar, err := cookiejar.New(nil)
+	is.NoErr(err)
+	client := NewClient(WithCookieJar(jar))
+	err = client.Run(ctx, NewRequest("mutation { login }", srv.URL), nil)
+	is.NoErr(err)
+	err = client.Run(ctx, NewRequest("query { me }", srv.URL), nil)
+	is.NoErr(err)
+	is.Equal(calls, 2)
+	is.Equal(http.DefaultClient.Jar, nil) // the default client is not modified
+}
+
+func TestAddCookie(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("Cookie"), "session=abc123; theme=dark")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	req := NewRequest("query {}", srv.URL)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	err := NewClient().Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
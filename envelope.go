@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WithResponseEnvelopePath makes Run look for the GraphQL response,
+// with its data, errors and extensions fields, at path in the response
+// body rather than at the top level, for gateways that wrap responses
+// in an envelope of their own. path is a list of object keys separated
+// by dots, such as "result" for a body like
+//
+//	{"result": {"data": {...}, "errors": [...]}}
+//
+// A response without the path results in a *DecodeError. Responses
+// are buffered to unwrap them, so WithStreamingResponses has no effect
+// with this option.
+func WithResponseEnvelopePath(path string) ClientOption {
+	return func(client *Client) {
+		client.envelopePath = nil
+		if path != "" {
+			client.envelopePath = strings.Split(path, ".")
+		}
+	}
+}
+
+// unwrapEnvelope returns the part of body at the path set with
+// WithResponseEnvelopePath, or body itself if no path is set.
+func (c *Client) unwrapEnvelope(body []byte) ([]byte, error) {
+	for i, key := range c.envelopePath {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(body, &object); err != nil {
+			return nil, errors.Wrap(err, "unwrapping response")
+		}
+		field, ok := object[key]
+		if !ok {
+			return nil, errors.Errorf("missing envelope field %s", strings.Join(c.envelopePath[:i+1], "."))
+		}
+		body = field
+	}
+	return body, nil
+}
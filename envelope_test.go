@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithResponseEnvelopePath(t *testing.T) {
+	is := is.New(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithResponseEnvelopePath("result"), WithStreamingResponses())
+	var resp struct {
+		Value string
+	}
+	body = `{"status":"ok","result":{"data":{"value":"some data"},"errors":[{"message":"partial"}]}}`
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), &resp)
+	is.Equal(err.Error(), "graphql: partial")
+	is.Equal(resp.Value, "some data")
+
+	body = `{"data":{"value":"some data"}}`
+	err = client.Run(ctx, NewRequest("query {}", srv.URL), &resp)
+	is.True(IsDecodeError(err))
+	is.Equal(err.Error(), "decoding response: missing envelope field result")
+
+	client = NewClient(WithResponseEnvelopePath("a.b"))
+	body = `{"a":{"b":{"data":{"value":"nested"}}}}`
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), &resp))
+	is.Equal(resp.Value, "nested")
+}
@@ -0,0 +1,165 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultErrorBodyLimit is the number of bytes of a response body kept
+// in errors unless changed with WithErrorBodyLimit.
+const defaultErrorBodyLimit = 2048
+
+// HTTPError is returned by Run when the server responds with a status
+// code outside the 2xx range, unless the response carries GraphQL
+// errors in the application/graphql-response+json media type, see
+// UseGraphQLResponseJSON. Use errors.As to branch on the status code:
+//
+//	var httpErr *graphql.HTTPError
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+//	    // refresh credentials
+//	}
+//
+// The message returned by Error is the same as the one returned by
+// earlier versions, so existing checks of the message keep working.
+type HTTPError struct {
+	// StatusCode is the status code of the response, such as 503.
+	StatusCode int
+	// Status is the status line of the response, such as
+	// "503 Service Unavailable".
+	Status string
+	// Body is the beginning of the body of the response, truncated to
+	// 2KB unless changed with WithErrorBodyLimit.
+	Body string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("graphql: server returned a non-200 status code: %v", e.StatusCode)
+}
+
+// newHTTPError returns an HTTPError for res with the given body, which
+// is truncated to the limit set with WithErrorBodyLimit.
+func (c *Client) newHTTPError(res *http.Response, body []byte) *HTTPError {
+	return &HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       c.errorBody(body),
+	}
+}
+
+// readHTTPError reads the beginning of the body of res, which has not
+// been read yet, and returns an HTTPError for it.
+func (c *Client) readHTTPError(res *http.Response) *HTTPError {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, int64(c.errorBodyLimit)))
+	return c.newHTTPError(res, body)
+}
+
+// errorBody returns the beginning of body that is kept in errors.
+func (c *Client) errorBody(body []byte) string {
+	if len(body) > c.errorBodyLimit {
+		body = body[:c.errorBodyLimit]
+	}
+	return string(body)
+}
+
+// NetworkError is returned by Run when the request could not be sent or
+// the response could not be read, for example because the host could not
+// be resolved, the connection was refused or the request timed out.
+// It wraps the error of the http.Client, so errors.Is(err,
+// context.DeadlineExceeded) still works.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned by Run when the body of a response is not a
+// valid GraphQL response, or its data cannot be unmarshaled into the
+// response object.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "decoding response: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ContentTypeError is wrapped in the *DecodeError returned by Run when
+// the body of a successful response is not JSON and its content type
+// is not a JSON type either, such as the HTML error page of a
+// misconfigured gateway.
+type ContentTypeError struct {
+	ContentType string
+	// Body holds the beginning of the body, up to 2KB unless changed
+	// with WithErrorBodyLimit.
+	Body string
+}
+
+func (e *ContentTypeError) Error() string {
+	snippet := strings.Join(strings.Fields(e.Body), " ")
+	if len(snippet) > 100 {
+		snippet = snippet[:100] + "..."
+	}
+	return fmt.Sprintf("unexpected content type %q: %s", e.ContentType, snippet)
+}
+
+// contentTypeError returns a *ContentTypeError for res if body, the
+// beginning of its body, is not JSON and res has a content type other
+// than JSON, or nil otherwise.
+func (c *Client) contentTypeError(res *http.Response, body []byte) error {
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" || isJSONMediaType(contentType) {
+		return nil
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return nil
+	}
+	return &ContentTypeError{ContentType: contentType, Body: c.errorBody(body)}
+}
+
+// IsNetworkError reports whether err is or wraps a *NetworkError. Such
+// errors are usually temporary and worth retrying.
+func IsNetworkError(err error) bool {
+	var e *NetworkError
+	return errors.As(err, &e)
+}
+
+// IsHTTPError reports whether err is or wraps an *HTTPError.
+func IsHTTPError(err error) bool {
+	var e *HTTPError
+	return errors.As(err, &e)
+}
+
+// IsDecodeError reports whether err is or wraps a *DecodeError.
+func IsDecodeError(err error) bool {
+	var e *DecodeError
+	return errors.As(err, &e)
+}
+
+// IsGraphQLError reports whether err is or wraps a *GraphQLError.
+func IsGraphQLError(err error) bool {
+	var e *GraphQLError
+	return errors.As(err, &e)
+}
+
+// isSuccess reports whether code is a 2xx status code.
+func isSuccess(code int) bool {
+	return code >= 200 && code <= 299
+}
@@ -0,0 +1,240 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestHTTPError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"errors": [{"message": "not authorized"}]}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusUnauthorized)
+	is.Equal(httpErr.Status, "401 Unauthorized")
+	is.Equal(httpErr.Body, `{"errors": [{"message": "not authorized"}]}`)
+	is.Equal(err.Error(), "graphql: server returned a non-200 status code: 401")
+}
+
+func TestHTTPErrorTruncatesBody(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, strings.Repeat("x", 10000))
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithRetry(1, nil))
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusBadGateway)
+	is.Equal(len(httpErr.Body), 2048)
+}
+
+func TestWithErrorBodyLimit(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, strings.Repeat("x", 10000))
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, tt := range []struct {
+		limit int
+		opts  []ClientOption
+		want  int
+	}{
+		{limit: 100, want: 100},
+		{limit: 4096, want: 4096},
+		{limit: 4096, opts: []ClientOption{WithStreamingResponses()}, want: 4096},
+		{limit: 0, want: 0},
+		{limit: -1, want: 0},
+	} {
+		client := NewClient(append(tt.opts, WithErrorBodyLimit(tt.limit))...)
+		err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+		var httpErr *HTTPError
+		is.True(errors.As(err, &httpErr))
+		is.Equal(len(httpErr.Body), tt.want)
+	}
+}
+
+func TestSuccessStatusCodes(t *testing.T) {
+	is := is.New(t)
+	for _, tt := range []struct {
+		status int
+		body   string
+		want   map[string]interface{}
+		err    string
+	}{
+		{status: http.StatusOK, body: `{"data": {"something": "yes"}}`, want: map[string]interface{}{"something": "yes"}},
+		{status: http.StatusAccepted, body: `{"data": {"something": "yes"}}`, want: map[string]interface{}{"something": "yes"}},
+		{status: http.StatusNoContent},
+		{status: http.StatusInternalServerError, body: `{"data": {"something": "yes"}}`, err: "graphql: server returned a non-200 status code: 500"},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.body)
+		}))
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+
+		client := NewClient()
+		var resp map[string]interface{}
+		err := client.Run(ctx, NewRequest("query {}", srv.URL), &resp)
+		cancel()
+		srv.Close()
+		if tt.err != "" {
+			is.Equal(err.Error(), tt.err) // status
+			is.Equal(resp, nil)           // status
+			continue
+		}
+		is.NoErr(err) // status
+		is.Equal(resp, tt.want)
+	}
+}
+
+func TestNoContentStrictNoData(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithStrictNoData())
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.Equal(err, ErrNoData)
+}
+
+func TestErrorClassification(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/decode":
+			io.WriteString(w, `{"data":`)
+		case "/graphql":
+			io.WriteString(w, `{"errors":[{"message":"miss"}]}`)
+		}
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	for endpoint, want := range map[string]string{
+		closed.URL:           "network",
+		srv.URL + "/http":    "http",
+		srv.URL + "/decode":  "decode",
+		srv.URL + "/graphql": "graphql",
+	} {
+		err := client.Run(ctx, NewRequest("query {}", endpoint), nil)
+		is.True(err != nil)
+		is.Equal(IsNetworkError(err), want == "network") // network
+		is.Equal(IsHTTPError(err), want == "http")       // http
+		is.Equal(IsDecodeError(err), want == "decode")   // decode
+		is.Equal(IsGraphQLError(err), want == "graphql") // graphql
+	}
+}
+
+func TestNetworkErrorTimeout(t *testing.T) {
+	is := is.New(t)
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := NewClient(WithHTTPClient(testClient)).Run(ctx, NewRequest("query {}", "http://example.com/graphql"), nil)
+	is.True(IsNetworkError(err))
+	is.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestGraphQLErrorCodes(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":[
+			{"message":"not logged in","extensions":{"code":"UNAUTHENTICATED"}},
+			{"message":"too many requests","extensions":{"code":"RATE_LIMITED","details":{"retryAfter":30}}},
+			{"message":"no code"}
+		]}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	err := NewClient().Run(ctx, NewRequest("query {}", srv.URL), nil)
+	var gqlErr *GraphQLError
+	is.True(errors.As(err, &gqlErr))
+	is.True(gqlErr.HasCode("UNAUTHENTICATED"))
+	is.True(gqlErr.HasCode("RATE_LIMITED"))
+	is.True(!gqlErr.HasCode("FORBIDDEN"))
+	is.Equal(gqlErr.Errors[0].Code(), "UNAUTHENTICATED")
+	is.Equal(gqlErr.Errors[1].Extension("details"), map[string]interface{}{"retryAfter": float64(30)})
+	is.Equal(gqlErr.Errors[2].Code(), "")
+	is.Equal(gqlErr.Errors[2].Extension("code"), nil)
+}
+
+func TestContentTypeError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, "<html>\n  <body>\n    <h1>Welcome to nginx!</h1>\n  </body>\n</html>"+strings.Repeat(" ", 4096))
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, opts := range [][]ClientOption{nil, {WithStreamingResponses()}} {
+		err := NewClient(opts...).Run(ctx, NewRequest("query {}", srv.URL), nil)
+		is.True(IsDecodeError(err))
+		var ctErr *ContentTypeError
+		is.True(errors.As(err, &ctErr))
+		is.Equal(ctErr.ContentType, "text/html; charset=utf-8")
+		is.Equal(len(ctErr.Body), 2048)
+		is.Equal(err.Error(), `decoding response: unexpected content type "text/html; charset=utf-8": <html> <body> <h1>Welcome to nginx!</h1> </body> </html>`)
+	}
+}
+
+func TestContentTypeJSONAsText(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var resp struct{ Value string }
+	is.NoErr(NewClient().Run(ctx, NewRequest("query {}", srv.URL), &resp)) // JSON is decoded whatever its content type
+	is.Equal(resp.Value, "some data")
+}
@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WithEndpoints sets several default endpoints for the Client, such as
+// the same API deployed in different regions. Requests made with an
+// empty endpoint are sent to the first one, and fail over to the next
+// endpoints in order when they fail with a *NetworkError or a 5xx
+// status code (or the status codes set with WithRetryStatus).
+// If every endpoint fails, a *FailoverError listing each failure is
+// returned.
+//
+// With WithRetry, maxAttempts counts the attempts across all endpoints:
+// once the last endpoint has failed, the request is sent to the first
+// one again after the backoff, until maxAttempts is reached. Without
+// WithRetry, every endpoint is tried once. As with WithRetry, mutations
+// only fail over when they carry an idempotency key.
+//
+// If any endpoint is not a valid http or https URL, every call to Run
+// returns the error reported by ValidateEndpoint.
+func WithEndpoints(endpoints ...string) ClientOption {
+	return func(client *Client) {
+		if len(endpoints) == 0 {
+			client.err = errors.New("graphql: no endpoints given to WithEndpoints")
+			return
+		}
+		for _, endpoint := range endpoints {
+			if err := ValidateEndpoint(endpoint); err != nil {
+				client.err = err
+			}
+		}
+		client.endpoint = endpoints[0]
+		client.endpoints = endpoints
+	}
+}
+
+// EndpointError is the failure of a request sent to one endpoint.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+// FailoverError is returned by Run when a request failed on every
+// endpoint set with WithEndpoints. It unwraps to the last failure, so
+// IsNetworkError and IsHTTPError report on it.
+type FailoverError struct {
+	Errors []EndpointError
+}
+
+func (e *FailoverError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Endpoint + ": " + err.Err.Error()
+	}
+	return "graphql: all endpoints failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the last failure.
+func (e *FailoverError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[len(e.Errors)-1].Err
+}
+
+// endpointsFor returns the endpoints req is sent to in turn.
+func (c *Client) endpointsFor(req *Request) []string {
+	if req.defaultEndpoint && len(c.endpoints) > 1 {
+		return c.endpoints
+	}
+	return []string{req.Endpoint}
+}
+
+// retryStatusCode reports whether a response with the given status code
+// is worth retrying or sending to the next endpoint.
+func (c *Client) retryStatusCode(code int) bool {
+	if c.retryStatus != nil {
+		return c.retryStatus[code]
+	}
+	return len(c.endpoints) > 1 && code >= 500
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithEndpoints(t *testing.T) {
+	is := is.New(t)
+	refused := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	refused.Close()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithEndpoints(refused.URL, srv.URL))
+	var resp struct {
+		Value string
+	}
+	is.NoErr(client.Run(ctx, NewRequest("query { value }", ""), &resp))
+	is.Equal(resp.Value, "some data")
+	is.Equal(calls, 1)
+
+	// requests with an endpoint do not fail over
+	err := client.Run(ctx, NewRequest("query { value }", refused.URL), &resp)
+	is.True(IsNetworkError(err))
+	is.Equal(calls, 1)
+}
+
+func TestWithEndpointsAllFail(t *testing.T) {
+	is := is.New(t)
+	refused := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	refused.Close()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithEndpoints(refused.URL, srv.URL))
+	err := client.Run(ctx, NewRequest("query {}", ""), nil)
+	var failoverErr *FailoverError
+	is.True(errors.As(err, &failoverErr))
+	is.Equal(len(failoverErr.Errors), 2)
+	is.Equal(failoverErr.Errors[0].Endpoint, refused.URL)
+	is.True(IsNetworkError(failoverErr.Errors[0].Err))
+	is.Equal(failoverErr.Errors[1].Endpoint, srv.URL)
+	is.True(strings.HasSuffix(err.Error(), srv.URL+": graphql: server returned a non-200 status code: 500"))
+	is.True(IsHTTPError(err)) // unwraps to the last failure
+	is.Equal(calls, 1)
+}
+
+func TestWithEndpointsRetry(t *testing.T) {
+	is := is.New(t)
+	var order []string
+	newServer := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, name)
+			w.WriteHeader(status)
+			io.WriteString(w, `{"data":{}}`)
+		}))
+	}
+	a := newServer("a", http.StatusServiceUnavailable)
+	defer a.Close()
+	b := newServer("b", http.StatusBadGateway)
+	defer b.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var backoffs []int
+	client := NewClient(WithEndpoints(a.URL, b.URL), WithRetry(3, func(attempt int) time.Duration {
+		backoffs = append(backoffs, attempt)
+		return time.Millisecond
+	}))
+	err := client.Run(ctx, NewRequest("query {}", ""), nil)
+	var failoverErr *FailoverError
+	is.True(errors.As(err, &failoverErr))
+	is.Equal(order, []string{"a", "b", "a"}) // attempts are not double counted
+	is.Equal(backoffs, []int{1})             // backoff before starting over
+}
+
+func TestWithEndpointsGraphQLError(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"errors":[{"message":"miss"}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithEndpoints(srv.URL, srv.URL))
+	err := client.Run(context.Background(), NewRequest("query {}", ""), nil)
+	is.True(IsGraphQLError(err))
+	is.Equal(calls, 1) // GraphQL errors do not fail over
+}
+
+func TestWithEndpointsInvalid(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(WithEndpoints("https://example.com/graphql", "example.com"))
+	err := client.Run(context.Background(), NewRequest("query {}", ""), nil)
+	is.Equal(err.Error(), `graphql: invalid endpoint "example.com": scheme must be http or https`)
+}
@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// UseGETForQueries sends queries as GET requests, with the query and
+// variables encoded in the URL, so they can be cached by CDNs and
+// gateways. Mutations and subscriptions are still sent as POST
+// requests, as are all requests with the UseMultipartForm option.
+// Combined with WithPersistedQueries, queries are sent as GET requests
+// with only the hash of the query, see WithPersistedQueries.
+func UseGETForQueries() ClientOption {
+	return func(client *Client) {
+		client.useGET = true
+	}
+}
+
+// usesGET reports whether req is sent as a GET request.
+func (c *Client) usesGET(req *Request) bool {
+	return req.method == http.MethodGet || req.method == "" && c.useGET && selectedOperationType(req.q, req.operationName) == "query"
+}
+
+// newGETRequest builds a GET request for req with the fields of body in
+// the query string.
+func (c *Client) newGETRequest(req *Request, body jsonBody) (*http.Request, error) {
+	u, err := url.Parse(req.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	params := u.Query()
+	if body.Query != "" {
+		params.Set("query", body.Query)
+	}
+	if body.OperationName != "" {
+		params.Set("operationName", body.OperationName)
+	}
+	if len(body.Variables) > 0 {
+		if err := c.setJSONParam(params, "variables", body.Variables); err != nil {
+			return nil, errors.Wrap(err, "encode variables")
+		}
+	}
+	if len(body.Extensions) > 0 {
+		if err := c.setJSONParam(params, "extensions", body.Extensions); err != nil {
+			return nil, errors.Wrap(err, "encode extensions")
+		}
+	}
+	u.RawQuery = params.Encode()
+	c.logf(">> variables: %v", c.redactVars(req))
+	c.logf(">> query: %s", body.Query)
+	return http.NewRequest(http.MethodGet, u.String(), nil)
+}
+
+// setJSONParam sets the query parameter key to v encoded as JSON.
+func (c *Client) setJSONParam(params url.Values, key string, v interface{}) error {
+	var buf bytes.Buffer
+	if err := c.newEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	params.Set(key, string(bytes.TrimSpace(buf.Bytes())))
+	return nil
+}
+
+// withoutQuery returns err with the query string removed from its URL
+// if it is a *url.Error, as the query string of a GET request holds the
+// variables, which must not show up in errors and logs unredacted.
+func withoutQuery(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil || u.RawQuery == "" {
+		return err
+	}
+	u.RawQuery = ""
+	stripped := *urlErr
+	stripped.URL = u.String()
+	return &stripped
+}
@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestUseGETForQueries(t *testing.T) {
+	is := is.New(t)
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.Method {
+		case http.MethodGet:
+			is.Equal(r.URL.Query().Get("query"), "query ($id: ID!) { user(id: $id) { name } }")
+			is.Equal(r.URL.Query().Get("variables"), `{"id":"123"}`)
+			is.Equal(r.URL.Query().Get("tenant"), "acme") // existing parameters are kept
+		case http.MethodPost:
+			b, err := ioutil.ReadAll(r.Body)
+			is.NoErr(err)
+			is.Equal(string(b), `{"query":"# create\nmutation { createUser { id } }","variables":null}`+"\n")
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(UseGETForQueries())
+	req := NewRequest("query ($id: ID!) { user(id: $id) { name } }", srv.URL+"?tenant=acme")
+	req.Var("id", "123")
+	is.NoErr(client.Run(ctx, req, nil))
+	is.NoErr(client.Run(ctx, NewRequest("# create\nmutation { createUser { id } }", srv.URL), nil))
+	is.Equal(methods, []string{http.MethodGet, http.MethodPost})
+}
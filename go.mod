@@ -1,8 +1,14 @@
 module github.com/donutloop/graphql
 
-go 1.12
+go 1.18
 
 require (
+	github.com/gorilla/websocket v1.4.2
 	github.com/matryer/is v1.2.0
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
+	golang.org/x/net v0.7.0
+	golang.org/x/sync v0.1.0
+	golang.org/x/time v0.3.0
 )
+
+require golang.org/x/text v0.7.0 // indirect
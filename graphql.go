@@ -33,24 +33,221 @@ package graphql
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"io"
+	"io/fs"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 )
 
 // Client is a client for interacting with a GraphQL API.
+// A Client is safe for concurrent use by multiple goroutines once it
+// is created by NewClient, and should be reused rather than created
+// for every request, as it holds the caches and connections.
 type Client struct {
+	// err is a configuration error returned by every call to Run.
+	err error
+
+	endpoint         string
+	endpoints        []string
 	httpClient       *http.Client
 	useMultipartForm bool
+	boundary         string
+	useGET           bool
 
 	// closeReq will close the request body immediately allowing for reuse of client
 	closeReq bool
 
-	// Log is called with various debug information.
-	// To log to standard out, use:
+	// newEncoder and newDecoder create the JSON encoders and decoders
+	// used for requests and responses.
+	newEncoder func(io.Writer) Encoder
+	newDecoder func(io.Reader) Decoder
+
+	// indentJSON indents request bodies, see WithCompactJSON.
+	indentJSON bool
+
+	// envelopePath is the path of the GraphQL response in response
+	// bodies, see WithResponseEnvelopePath.
+	envelopePath []string
+
+	// canonicalJSON sorts the keys of request bodies, see
+	// WithCanonicalJSON.
+	canonicalJSON bool
+
+	// validators check requests before they are sent, see
+	// WithRequestValidator.
+	validators []func(*Request) error
+
+	// readOnly rejects mutations and subscriptions, see ReadOnly.
+	readOnly bool
+
+	// allowlist holds the hashes of the queries the Client may send, see
+	// WithOperationAllowlist.
+	allowlist map[string]bool
+
+	// jar is the cookie jar of httpClient, see WithCookieJar.
+	jar http.CookieJar
+
+	// pool tunes the transport of httpClient, see WithConnectionPool.
+	pool *connectionPool
+
+	// limiter throttles requests, see WithRateLimiter.
+	limiter *rate.Limiter
+
+	// beforeRequest and afterResponse are called around every HTTP
+	// request, see WithBeforeRequest and WithAfterResponse.
+	beforeRequest []func(context.Context, *http.Request) error
+	afterResponse []func(context.Context, *http.Response) error
+
+	// disableKeepAlives closes connections after every request, see
+	// DisableKeepAlives.
+	disableKeepAlives bool
+
+	// insecureSkipVerify disables the verification of TLS
+	// certificates, see WithInsecureSkipVerify.
+	insecureSkipVerify bool
+
+	// http2 and h2c configure the transport of httpClient for HTTP/2,
+	// see WithHTTP2.
+	http2 bool
+	h2c   bool
+
+	// transports wrap the transport of httpClient, see WithTransport.
+	transports []func(http.RoundTripper) http.RoundTripper
+
+	// metrics is called after every Run, see WithMetrics.
+	metrics func(RequestMetrics)
+
+	// clientTrace is called with the timings of every Run, see
+	// WithClientTrace.
+	clientTrace func(RequestTimings)
+
+	// tracer creates spans around requests, see WithTracer.
+	tracer Tracer
+
+	// logger receives structured logs, see WithLogger.
+	logger Logger
+
+	// redactVar replaces variable values before they are logged.
+	redactVar func(key string, value interface{}) interface{}
+
+	// graphQLResponseJSON asks for application/graphql-response+json
+	// responses, see UseGraphQLResponseJSON.
+	graphQLResponseJSON bool
+
+	// acceptHeader is the Accept header of requests, see WithAccept.
+	acceptHeader string
+
+	// userAgent is the User-Agent header of requests, see WithUserAgent.
+	userAgent string
+
+	// header is sent with every request, see WithHeaders.
+	header http.Header
+
+	// contextHeaders are set from the context of requests, see
+	// WithContextHeader.
+	contextHeaders []contextHeader
+
+	// authorize sets the credentials of outgoing requests.
+	authorize func(ctx context.Context, r *http.Request) error
+
+	// minifyQueries removes insignificant characters from queries, see
+	// WithMinifyQueries.
+	minifyQueries bool
+
+	// strictNoData reports responses without data as ErrNoData.
+	strictNoData bool
+
+	// strictDecoding rejects unknown fields in the data of responses.
+	strictDecoding bool
+
+	// streamResponses decodes responses without buffering them, see
+	// WithStreamingResponses.
+	streamResponses bool
+
+	// maxRequestBytes limits the size of request bodies, see
+	// WithMaxRequestBytes.
+	maxRequestBytes int64
+
+	// maxResponseBytes limits the size of response bodies, see
+	// WithMaxResponseBytes.
+	maxResponseBytes int64
+
+	// errorBodyLimit is the number of bytes of a response body kept in
+	// errors, see WithErrorBodyLimit.
+	errorBodyLimit int
+
+	// compressMinBytes is the size from which JSON request bodies are
+	// compressed, see WithRequestCompression.
+	compressMinBytes int
+
+	// timeout is applied to contexts without a deadline, see WithTimeout.
+	timeout time.Duration
+
+	// maxAttempts, backoff, retryStatus and retryCodes configure
+	// retries, see WithRetry and WithRetryOnGraphQLError.
+	maxAttempts int
+	backoff     func(attempt int) time.Duration
+	retryStatus map[int]bool
+	retryCodes  map[string]bool
+
+	// pingQuery is the query sent by Ping, see WithPingQuery.
+	pingQuery string
+
+	// wsProtocol and wsPingInterval configure subscriptions.
+	wsProtocol     string
+	wsPingInterval time.Duration
+
+	// tlsConfig is the TLS configuration of the transport of httpClient,
+	// used by Subscribe.
+	tlsConfig *tls.Config
+
+	// ownTransport is the transport the Client created by cloning the
+	// default transport or the one of the http.Client set with
+	// WithHTTPClient, if any. Close closes its idle connections.
+	ownTransport idleCloser
+
+	// closeMu guards closed and subs, the running subscriptions that
+	// are ended by Close.
+	closeMu sync.Mutex
+	closed  bool
+	subs    map[*Subscription]struct{}
+
+	// cache holds the data of query responses, see WithResponseCache.
+	cache *responseCache
+
+	// flights deduplicates concurrent queries, see WithSingleFlight.
+	flights *singleflight.Group
+
+	// operationIDHeader is the header registered operation IDs are sent
+	// in, see WithRegisteredOperations.
+	operationIDHeader string
+
+	// persistedQueries enables Automatic Persisted Queries, with the
+	// hashes of queries computed by queryHashFunc, if set, and cached in
+	// queryHashes.
+	persistedQueries bool
+	queryHashFunc    func(string) string
+	queryHashesMu    sync.Mutex
+	queryHashes      map[string]string
+
+	// Log is called with various debug information. The values of the
+	// Authorization, Proxy-Authorization and Cookie headers are logged
+	// as "***". To log to standard out, use:
 	//  client.Log = func(s string) { log.Println(s) }
 	Log func(s string)
 }
@@ -58,13 +255,55 @@ type Client struct {
 // NewClient makes a new Client capable of making GraphQL requests.
 func NewClient(opts ...ClientOption) *Client {
 	c := &Client{
-		Log:      func(string) {},
+		Log:            func(string) {},
+		userAgent:      DefaultUserAgent,
+		errorBodyLimit: defaultErrorBodyLimit,
 	}
 	for _, optionFunc := range opts {
 		optionFunc(c)
 	}
-	if c.httpClient == nil {
-		c.httpClient = http.DefaultClient
+	configureHTTP2 := c.http2 && c.httpClient == nil
+	given := c.httpClient
+	if given == nil {
+		given = http.DefaultClient
+		c.httpClient = given
+		if c.insecureSkipVerify {
+			c.httpClient = skipVerify(c.httpClient)
+		}
+	}
+	if c.pool != nil {
+		c.httpClient = c.withPool(c.httpClient)
+	}
+	if c.disableKeepAlives {
+		c.httpClient = tuneTransport(c.httpClient, func(t *http.Transport) {
+			t.DisableKeepAlives = true
+		})
+	}
+	if configureHTTP2 {
+		c.httpClient = c.withHTTP2(c.httpClient)
+	}
+	if c.httpClient != given {
+		// the transport was cloned to apply the options above
+		c.ownTransport, _ = c.httpClient.Transport.(idleCloser)
+	}
+	c.tlsConfig = tlsConfigOf(c.httpClient.Transport)
+	if len(c.transports) > 0 {
+		c.httpClient = c.wrapTransport(c.httpClient)
+	}
+	if c.jar != nil {
+		c.httpClient = c.withJar(c.httpClient)
+	}
+	if c.newEncoder == nil && c.indentJSON {
+		c.newEncoder = newIndentedJSONEncoder
+	}
+	if c.newEncoder == nil {
+		c.newEncoder = newJSONEncoder
+	}
+	if c.canonicalJSON {
+		c.newEncoder = canonicalEncoder(c.newEncoder)
+	}
+	if c.newDecoder == nil {
+		c.newDecoder = newJSONDecoder
 	}
 	return c
 }
@@ -76,126 +315,428 @@ func (c *Client) logf(format string, args ...interface{}) {
 // Run executes the query and unmarshals the response from the data field
 // into the response object.
 // Pass in a nil response object to skip response parsing.
-// If the request fails or the server returns an error, the first error
-// will be returned.
+// The response object may be or contain a json.RawMessage to keep the
+// raw bytes of the data, or parts of it, for later decoding.
+// Any 2xx status code is a success, and an empty 204 No Content
+// response is treated as having no data. Other status codes are
+// reported as an *HTTPError.
+// If the request fails an error is returned. If the server responds
+// with GraphQL errors, a *GraphQLError is returned after any data in
+// the response has been unmarshaled into the response object.
 func (c *Client) Run(ctx context.Context, req *Request, resp interface{}) error {
+	if c.metrics != nil || c.tracer != nil || c.clientTrace != nil {
+		return c.runInstrumented(ctx, req, resp)
+	}
+	return c.run(ctx, req, resp)
+}
+
+// RunString executes query with the variables vars against endpoint and
+// unmarshals the data of the response into resp, like Run with a
+// request built by NewRequest and VarMap. An empty endpoint uses the one
+// set with WithEndpoint.
+//
+//	var resp struct{ User struct{ Name string } }
+//	err := client.RunString(ctx, "", `query ($id: ID!) { user(id: $id) { name } }`,
+//		map[string]interface{}{"id": "1"}, &resp)
+func (c *Client) RunString(ctx context.Context, endpoint, query string, vars map[string]interface{}, resp interface{}) error {
+	req := NewRequest(query, endpoint)
+	req.VarMap(vars)
+	return c.Run(ctx, req, resp)
+}
 
+func (c *Client) run(ctx context.Context, req *Request, resp interface{}) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
+	req, err := c.prepare(req)
+	if err != nil {
+		return err
+	}
 	if len(req.files) > 0 && !c.useMultipartForm {
 		return errors.New("cannot send files with PostFields option")
 	}
-	if c.useMultipartForm {
-		return c.runWithPostFields(ctx, req, resp)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	switch selectedOperationType(req.q, req.operationName) {
+	case "query":
+		if (c.cache != nil || c.flights != nil) && len(req.files) == 0 {
+			return c.runShared(ctx, req, resp)
+		}
+	default:
+		if c.cache != nil {
+			defer c.cache.invalidate(req.Endpoint)
+		}
 	}
-	return c.runWithJSON(ctx, req, resp)
+	return c.do(ctx, req, resp)
 }
 
-func (c *Client) runWithJSON(ctx context.Context, req *Request, resp interface{}) error {
-	var requestBody bytes.Buffer
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     req.q,
-		Variables: req.vars,
+// do executes req, retrying it or failing over to other endpoints if
+// enabled.
+func (c *Client) do(ctx context.Context, req *Request, resp interface{}) error {
+	if c.maxAttempts > 1 || len(c.endpointsFor(req)) > 1 {
+		return c.runWithRetry(ctx, req, resp)
 	}
-	if err := json.NewEncoder(&requestBody).Encode(requestBodyObj); err != nil {
-		return errors.Wrap(err, "encode body")
+	_, err := c.runOnce(ctx, req, resp)
+	return err
+}
+
+// withTimeout applies the timeout set with WithTimeout to ctx, unless
+// ctx already has a deadline.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return ctx, func() {}
 	}
-	c.logf(">> variables: %v", req.vars)
-	c.logf(">> query: %s", req.q)
-	r, err := http.NewRequest(http.MethodPost, req.Endpoint, &requestBody)
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// runOnce makes a single attempt at executing req. The returned bool
+// reports whether the attempt failed in a way that is worth retrying.
+func (c *Client) runOnce(ctx context.Context, req *Request, resp interface{}) (bool, error) {
+	if c.operationIDHeader != "" && req.operationID != "" && !c.useMultipartForm {
+		return c.runRegistered(ctx, req, resp)
+	}
+	if c.persistedQueries && !c.useMultipartForm {
+		return c.runPersisted(ctx, req, resp)
+	}
+	r, err := c.newRequest(req)
 	if err != nil {
-		return err
+		return false, err
 	}
-	r.Close = c.closeReq
-	r.Header.Set("Content-Type", "application/json; charset=utf-8")
-	r.Header.Set("Accept", "application/json; charset=utf-8")
-	for key, values := range req.Header {
-		for _, value := range values {
-			r.Header.Add(key, value)
+	retry, err := c.send(ctx, r, req, resp)
+	if body, ok := r.Body.(*pipeBody); ok {
+		// The files must no longer be read once the attempt is over,
+		// so that they can be rewound for the next one, and no upload
+		// progress is reported after Run returns. Once ctx is done
+		// nothing is retried, and a read of a file that blocks must
+		// not keep Run from returning.
+		body.Close()
+		body.wait(ctx)
+	}
+	return retry, err
+}
+
+// newRequest builds the HTTP request for req, using multipart form data
+// or JSON depending on the configuration of the Client.
+func (c *Client) newRequest(req *Request) (*http.Request, error) {
+	if c.useMultipartForm {
+		return c.newMultipartRequest(req)
+	}
+	if c.usesGET(req) {
+		return c.newGETRequest(req, newJSONBody(req))
+	}
+	return c.newJSONRequest(req, newJSONBody(req))
+}
+
+// send sends r on behalf of req and decodes the response into resp.
+// The returned bool reports whether the failure is worth retrying.
+func (c *Client) send(ctx context.Context, r *http.Request, req *Request, resp interface{}) (bool, error) {
+	r, err := c.prepareRequest(ctx, r, req.Header)
+	if err != nil {
+		return false, err
+	}
+	if c.logger != nil {
+		if label := req.label(); label != "" {
+			c.logger.Debug("graphql request", "endpoint", req.Endpoint, "operation", label, "query", req.q, "variables", c.redactVars(req))
+		} else {
+			c.logger.Debug("graphql request", "endpoint", req.Endpoint, "query", req.q, "variables", c.redactVars(req))
 		}
 	}
-	c.logf(">> headers: %v", r.Header)
-	r = r.WithContext(ctx)
-	res, err := c.httpClient.Do(r)
+	res, err := c.roundTrip(ctx, c.httpClientFor(req), r)
 	if err != nil {
-		return err
+		if !IsNetworkError(err) {
+			return false, err
+		}
+		if c.logger != nil {
+			c.logger.Error("graphql request failed", "endpoint", req.Endpoint, "error", err)
+		}
+		return ctx.Err() == nil, err
 	}
 	defer res.Body.Close()
-	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
-		if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("graphql: server returned a non-200 status code: %v", res.StatusCode)
+	req.captureHeader(res)
+	if err := c.afterReceive(ctx, res); err != nil {
+		return false, err
+	}
+	if c.logger != nil {
+		c.logger.Debug("graphql response", "endpoint", req.Endpoint, "status", res.StatusCode)
+	}
+	if stats, ok := ctx.Value(statsKey{}).(*callStats); ok {
+		stats.statusCode = res.StatusCode
+		res.Body = &countingReadCloser{ReadCloser: res.Body, n: &stats.bytesRead}
+	}
+	if c.retryStatusCode(res.StatusCode) {
+		return true, c.readHTTPError(res)
+	}
+	err = c.decodeResponse(res, req, resp)
+	return c.retryGraphQLError(err), err
+}
+
+// prepareRequest applies the headers and credentials configured on the
+// Client to r, followed by the given request headers, and returns r
+// with ctx attached.
+func (c *Client) prepareRequest(ctx context.Context, r *http.Request, headers ...http.Header) (*http.Request, error) {
+	r.Close = c.closeReq
+	r.Header.Set("Accept", c.accept())
+	c.setUserAgent(r.Header)
+	copyHeader(r.Header, c.header)
+	c.setContextHeaders(ctx, r.Header)
+	if c.authorize != nil {
+		if err := c.authorize(ctx, r); err != nil {
+			return nil, errors.Wrap(err, "authorize")
 		}
-		return errors.Wrap(err, "decoding response")
 	}
-	return nil
+	if c.tracer != nil {
+		c.tracer.Inject(ctx, r.Header)
+	}
+	for _, header := range headers {
+		copyHeader(r.Header, header)
+	}
+	c.logf(">> headers: %v", redactHeader(r.Header))
+	if body, ok := r.Body.(*pipeBody); ok {
+		body.ctx = ctx
+	}
+	return r.WithContext(ctx), nil
 }
 
-func (c *Client) runWithPostFields(ctx context.Context, req *Request, resp interface{}) error {
+// jsonBody is the JSON encoding of a Request.
+type jsonBody struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func newJSONBody(req *Request) jsonBody {
+	return jsonBody{
+		Query:         req.q,
+		OperationName: req.operationName,
+		Variables:     req.vars,
+	}
+}
+
+func (c *Client) newJSONRequest(req *Request, body jsonBody) (*http.Request, error) {
 	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
+	if err := c.newEncoder(&requestBody).Encode(body); err != nil {
+		return nil, errors.Wrap(err, "encode body")
+	}
+	c.logf(">> variables: %v", c.redactVars(req))
+	c.logf(">> query: %s", body.Query)
+	compress := c.compressMinBytes > 0 && requestBody.Len() >= c.compressMinBytes
+	if compress {
+		compressed, err := gzipBytes(requestBody.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		requestBody = *compressed
+	}
+	if err := c.checkRequestSize(requestBody.Len()); err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequest(req.httpMethod(), req.Endpoint, &requestBody)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if compress {
+		r.Header.Set("Content-Encoding", "gzip")
+	}
+	return r, nil
+}
+
+func (c *Client) newMultipartRequest(req *Request) (*http.Request, error) {
+	var head bytes.Buffer
+	target := &switchWriter{w: c.limitRequestWriter(&head)}
+	writer := multipart.NewWriter(target)
+	if c.boundary != "" {
+		writer.SetBoundary(c.boundary)
+	}
+	var err error
+	if req.hasFileVars() {
+		err = c.writeOperations(writer, req)
+	} else {
+		err = c.writeQueryFields(writer, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.logf(">> variables: %v", c.redactVars(req))
+	c.logf(">> files: %d", len(req.files))
+	c.logf(">> query: %s", req.q)
+	var body io.Reader = &head
+	if c.maxRequestBytes > 0 {
+		// The size limit must be checked before the request is sent,
+		// so the body is built in memory, up to the limit.
+		if err := writeFiles(context.Background(), writer, req.files, newUploadProgress(req.uploadProgress, req.files)); err != nil {
+			return nil, err
+		}
+	} else {
+		body = newPipeBody(func(ctx context.Context, w io.Writer) error {
+			if _, err := head.WriteTo(w); err != nil {
+				return err
+			}
+			target.w = w
+			return writeFiles(ctx, writer, req.files, newUploadProgress(req.uploadProgress, req.files))
+		})
+	}
+	r, err := http.NewRequest(req.httpMethod(), req.Endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r, nil
+}
+
+// writeQueryFields writes the query and variables of req as separate
+// form fields.
+func (c *Client) writeQueryFields(writer *multipart.Writer, req *Request) error {
 	if err := writer.WriteField("query", req.q); err != nil {
 		return errors.Wrap(err, "write query field")
 	}
-	var variablesBuf bytes.Buffer
+	if req.operationName != "" {
+		if err := writer.WriteField("operationName", req.operationName); err != nil {
+			return errors.Wrap(err, "write operationName field")
+		}
+	}
 	if len(req.vars) > 0 {
 		variablesField, err := writer.CreateFormField("variables")
 		if err != nil {
 			return errors.Wrap(err, "create variables field")
 		}
-		if err := json.NewEncoder(io.MultiWriter(variablesField, &variablesBuf)).Encode(req.vars); err != nil {
+		if err := c.newEncoder(variablesField).Encode(req.vars); err != nil {
 			return errors.Wrap(err, "encode variables")
 		}
 	}
-	for i := range req.files {
-		part, err := writer.CreateFormFile(req.files[i].Field, req.files[i].Name)
-		if err != nil {
-			return errors.Wrap(err, "create form file")
-		}
-		if _, err := io.Copy(part, req.files[i].R); err != nil {
-			return errors.Wrap(err, "preparing file")
+	return nil
+}
+
+// decodeResponse reads the body of res and unmarshals its data field
+// into resp.
+func (c *Client) decodeResponse(res *http.Response, req *Request, resp interface{}) error {
+	if c.streamResponses && c.envelopePath == nil {
+		return c.decodeStream(res, req, resp)
+	}
+	buf, err := readBody(res, c.maxResponseBytes)
+	if err != nil {
+		return err
+	}
+	c.logf("<< %s", buf.String())
+	if !isSuccess(res.StatusCode) {
+		return c.statusError(res, buf.Bytes(), req, resp)
+	}
+	if res.StatusCode == http.StatusNoContent && buf.Len() == 0 {
+		if c.strictNoData {
+			return ErrNoData
 		}
+		return nil
 	}
-	if err := writer.Close(); err != nil {
-		return errors.Wrap(err, "close writer")
+	if err := c.decodeBody(buf.Bytes(), req, resp); err != nil {
+		if ctErr := c.contentTypeError(res, buf.Bytes()); ctErr != nil && IsDecodeError(err) {
+			err = &DecodeError{Err: ctErr}
+		}
+		if _, ok := err.(*GraphQLError); !ok && c.logger != nil {
+			c.logger.Error("graphql decode failed", "endpoint", req.Endpoint, "status", res.StatusCode, "error", err)
+		}
+		return err
 	}
-	c.logf(">> variables: %s", variablesBuf.String())
-	c.logf(">> files: %d", len(req.files))
-	c.logf(">> query: %s", req.q)
-	r, err := http.NewRequest(http.MethodPost, req.Endpoint, &requestBody)
+	return nil
+}
+
+// decodeBody unmarshals the data field of a single GraphQL response
+// into resp, and its extensions field into the destination set with
+// req.ResponseExtensions.
+func (c *Client) decodeBody(body []byte, req *Request, resp interface{}) error {
+	body, err := c.unwrapEnvelope(body)
 	if err != nil {
-		return err
+		return &DecodeError{Err: err}
 	}
-	r.Close = c.closeReq
-	r.Header.Set("Content-Type", writer.FormDataContentType())
-	r.Header.Set("Accept", "application/json; charset=utf-8")
-	for key, values := range req.Header {
+	gr := &graphResponse{
+		Extensions: req.extensions,
+	}
+	if err := c.newDecoder(bytes.NewReader(body)).Decode(gr); err != nil {
+		return &DecodeError{Err: err}
+	}
+	hasData := len(gr.Data) > 0 && !bytes.Equal(gr.Data, []byte("null"))
+	if hasData && resp != nil {
+		if err := c.decodeData(gr.Data, resp); err != nil {
+			return &DecodeError{Err: err}
+		}
+	}
+	if hasData {
+		if err := req.checkTypenames(gr.Data); err != nil {
+			return err
+		}
+	}
+	return c.responseError(gr.Errors, hasData)
+}
+
+// decodeData unmarshals the data field of a response into resp.
+func (c *Client) decodeData(data []byte, resp interface{}) error {
+	dec := c.newDecoder(bytes.NewReader(data))
+	if strict, ok := dec.(interface{ DisallowUnknownFields() }); ok && c.strictDecoding {
+		strict.DisallowUnknownFields()
+	}
+	return dec.Decode(resp)
+}
+
+// responseError returns the error for a response with the given errors
+// field, and data if hasData is set.
+func (c *Client) responseError(errs []ErrorEntry, hasData bool) error {
+	if len(errs) > 0 {
+		return &GraphQLError{Errors: errs}
+	}
+	if !hasData && c.strictNoData {
+		return ErrNoData
+	}
+	return nil
+}
+
+// copyHeader copies the values of src into dst. Keys present in src
+// replace any value already set in dst, so a header explicitly set on
+// the Request takes precedence over the defaults chosen by the Client.
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst.Del(key)
 		for _, value := range values {
-			r.Header.Add(key, value)
+			dst.Add(key, value)
+		}
+	}
+}
+
+// WithEndpoint sets the default endpoint of the Client, which is used
+// for requests made with an empty endpoint.
+// If endpoint is not a valid http or https URL, every call to Run
+// returns the error reported by ValidateEndpoint.
+//
+//	client := NewClient(WithEndpoint("https://machinebox.io/graphql"))
+//	req := NewRequest(query, "")
+func WithEndpoint(endpoint string) ClientOption {
+	return func(client *Client) {
+		client.endpoint = endpoint
+		client.endpoints = nil
+		if err := ValidateEndpoint(endpoint); err != nil {
+			client.err = err
 		}
 	}
-	c.logf(">> headers: %v", r.Header)
-	r = r.WithContext(ctx)
-	res, err := c.httpClient.Do(r)
+}
+
+// ValidateEndpoint returns an error describing why endpoint cannot be
+// used as the endpoint of a request, or nil if it is an absolute http or
+// https URL. Run validates endpoints before sending requests; use
+// ValidateEndpoint to check configuration up front.
+func ValidateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return errors.New("graphql: endpoint is empty")
+	}
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "graphql: invalid endpoint %q", endpoint)
 	}
-	defer res.Body.Close()
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, res.Body); err != nil {
-		return errors.Wrap(err, "reading body")
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("graphql: invalid endpoint %q: scheme must be http or https", endpoint)
 	}
-	c.logf("<< %s", buf.String())
-	if err := json.NewDecoder(&buf).Decode(resp); err != nil {
-		if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("graphql: server returned a non-200 status code: %v", res.StatusCode)
-		}
-		return errors.Wrap(err, "decoding response")
+	if u.Host == "" {
+		return errors.Errorf("graphql: invalid endpoint %q: missing host", endpoint)
 	}
 	return nil
 }
@@ -211,6 +752,10 @@ func WithHTTPClient(httpclient *http.Client) ClientOption {
 
 // UseMultipartForm uses multipart/form-data and activates support for
 // files.
+// Files are streamed to the server while the request is sent, so they
+// are never held in memory and their readers need not support seeking,
+// unless WithMaxRequestBytes is used: the whole body is then built in
+// memory, up to the limit, to check its size before it is sent.
 func UseMultipartForm() ClientOption {
 	return func(client *Client) {
 		client.useMultipartForm = true
@@ -224,36 +769,202 @@ func ImmediatelyCloseReqBody() ClientOption {
 	}
 }
 
+// WithTimeout sets a timeout for requests made with a context that has
+// no deadline. It is a safety net against requests hanging forever when
+// the caller forgets to set one; a deadline already set on the context
+// is always respected as is, and never extended or shortened.
+// When retries are enabled, the timeout covers all attempts.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.timeout = d
+	}
+}
+
+// WithStrictNoData makes Run return ErrNoData when the server responds
+// with a missing or null data field and no errors, which otherwise
+// succeeds without unmarshaling anything. GraphQL errors take
+// precedence over ErrNoData.
+func WithStrictNoData() ClientOption {
+	return func(client *Client) {
+		client.strictNoData = true
+	}
+}
+
+// WithStrictResponseDecoding makes Run fail when the data field of a
+// response contains fields that the response object does not have,
+// which helps to catch schema changes early. The top level data, errors
+// and extensions fields of the response are always accepted.
+// It requires a Decoder with a DisallowUnknownFields method, such as
+// the default *json.Decoder.
+func WithStrictResponseDecoding() ClientOption {
+	return func(client *Client) {
+		client.strictDecoding = true
+	}
+}
+
+// WithMinifyQueries removes comments and insignificant whitespace from
+// queries before they are sent, which makes requests smaller and keeps
+// cache keys and persisted query hashes stable across formatting
+// changes. Strings in queries are never changed.
+func WithMinifyQueries() ClientOption {
+	return func(client *Client) {
+		client.minifyQueries = true
+	}
+}
+
 // ClientOption are functions that are passed into NewClient to
 // modify the behaviour of the Client.
 type ClientOption func(*Client)
 
-type graphErr struct {
-	Message string
+// ErrNoData is returned by Run when the response has neither data nor
+// errors and the WithStrictNoData option is used.
+var ErrNoData = errors.New("graphql: response contains no data")
+
+// GraphQLError is returned by Run when the server responds with a
+// non-empty errors field. If the response also contains data, such as
+// the fields that resolved when others failed, the data is unmarshaled
+// into the response object before the GraphQLError is returned, so
+// callers can use the partial data, the errors, or both.
+// Use errors.As to inspect the individual entries:
+//
+//	var gqlErr *graphql.GraphQLError
+//	if errors.As(err, &gqlErr) {
+//	    for _, e := range gqlErr.Errors {
+//	        log.Println(e.Message, e.Extensions)
+//	    }
+//	}
+type GraphQLError struct {
+	Errors []ErrorEntry
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Errors) == 0 {
+		return "graphql: unknown error"
+	}
+	msg := "graphql: " + e.Errors[0].Message
+	if n := len(e.Errors) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more errors)", n)
+	}
+	return msg
 }
 
-func (e graphErr) Error() string {
-	return "graphql: " + e.Message
+// HasCode reports whether any of the errors has the given error code,
+// see ErrorEntry.Code.
+//
+//	var gqlErr *graphql.GraphQLError
+//	if errors.As(err, &gqlErr) && gqlErr.HasCode("UNAUTHENTICATED") {
+//	    // log in again
+//	}
+func (e *GraphQLError) HasCode(code string) bool {
+	for _, entry := range e.Errors {
+		if entry.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorEntry is a single entry of the errors field of a GraphQL
+// response.
+type ErrorEntry struct {
+	Message    string                 `json:"message"`
+	Locations  []ErrorLocation        `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Extension returns the value of the extensions field key of the entry,
+// or nil if it is not set.
+func (e ErrorEntry) Extension(key string) interface{} {
+	return e.Extensions[key]
+}
+
+// Code returns the machine-readable error code that servers commonly
+// put in the code extension, such as "UNAUTHENTICATED", or an empty
+// string if there is none.
+func (e ErrorEntry) Code() string {
+	code, _ := e.Extension("code").(string)
+	return code
+}
+
+// ErrorLocation is a position in the query document an ErrorEntry
+// refers to.
+type ErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 type graphResponse struct {
-	Data   interface{}
-	Errors []graphErr
+	Data       json.RawMessage
+	Errors     []ErrorEntry
+	Extensions interface{}
 }
 
 // Request is a GraphQL request.
+// A Request must not be modified while it is run. It may be run by
+// several goroutines at once, unless ResponseExtensions or
+// CaptureResponseHeaders is used, as they store the results of every
+// run in the same place; use Clone to run variations of a request
+// concurrently.
 type Request struct {
 	Endpoint string
 	q     string
 	vars  map[string]interface{}
 	files []File
 
+	// operationName selects the operation of the query to execute.
+	operationName string
+
+	// opName names the operation in metrics.
+	opName string
+
+	// method is the HTTP method of the request, see Method.
+	method string
+
+	// operationID is the registered ID of the operation, see
+	// RegisteredOperationID.
+	operationID string
+
+	// redacted holds the variables that are not logged, see RedactVar.
+	redacted map[string]bool
+
+	// typenames holds the expected types of objects in the response by
+	// path, see ExpectTypename.
+	typenames map[string][]string
+
+	// uploadProgress is called as files are written, see
+	// OnUploadProgress.
+	uploadProgress func(bytesWritten, totalBytes int64)
+
+	// httpClient sends the request instead of the http.Client of the
+	// Client, see WithHTTPClient.
+	httpClient *http.Client
+
+	// defaultEndpoint reports whether Endpoint was set from the
+	// default endpoint of the Client.
+	defaultEndpoint bool
+
+	// err is an error that occurred while building the request. It is
+	// returned by Run.
+	err error
+
+	// extensions is where the extensions field of the response is
+	// unmarshaled to, if set.
+	extensions interface{}
+
+	// responseHeader is where the headers of the response are stored,
+	// see CaptureResponseHeaders.
+	responseHeader *http.Header
+
 	// Header represent any request headers that will be set
-	// when the request is made.
+	// when the request is made. Values set here replace those
+	// the Client would otherwise send, such as Content-Type.
 	Header http.Header
 }
 
 // NewRequest makes a new Request with the specified string.
+// If endpoint is empty, the request is sent to the endpoint set on the
+// Client with WithEndpoint.
 func NewRequest(q string, endpoint string) *Request {
 	req := &Request{
 		q:      q,
@@ -263,7 +974,160 @@ func NewRequest(q string, endpoint string) *Request {
 	return req
 }
 
-// Var sets a variable.
+// NewRequestFromReader makes a new Request with the query read from r.
+// r is read to the end before NewRequestFromReader returns, so it can be
+// closed right after.
+func NewRequestFromReader(r io.Reader, endpoint string) (*Request, error) {
+	q, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read query")
+	}
+	return NewRequest(string(q), endpoint), nil
+}
+
+// NewRequestFromFS makes a new Request with the query read from the
+// named file in fsys, such as an embed.FS.
+func NewRequestFromFS(fsys fs.FS, name, endpoint string) (*Request, error) {
+	q, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "read query")
+	}
+	return NewRequest(string(q), endpoint), nil
+}
+
+// OperationName sets the name of the operation to execute, which is
+// required when the query contains more than one operation. It is sent
+// with the query in every request encoding, and Run returns an error
+// without sending the request if the query defines no operation of
+// that name.
+func (req *Request) OperationName(name string) {
+	req.operationName = name
+}
+
+// OpName sets the name the operation is reported with in
+// RequestMetrics, spans and logs. It is not sent to the server, and
+// defaults to the name set with OperationName, or else to the name of
+// the first named operation in the query.
+func (req *Request) OpName(name string) {
+	req.opName = name
+}
+
+// Method sets the HTTP method used to send the request, which defaults
+// to POST, or GET for queries with the UseGETForQueries option. With
+// GET, the query and variables are encoded in the URL; with any other
+// method they are sent in the body. Method takes precedence over
+// UseGETForQueries. If m is not a known HTTP method, the error is
+// returned by Run.
+func (req *Request) Method(m string) {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		req.method = m
+	default:
+		req.err = errors.Errorf("graphql: unknown HTTP method %q", m)
+	}
+}
+
+// httpMethod returns the HTTP method of requests with a body.
+func (req *Request) httpMethod() string {
+	if req.method != "" {
+		return req.method
+	}
+	return http.MethodPost
+}
+
+// label returns the name of the operation for metrics, spans and logs.
+// Without OpName or OperationName, it is the name of the first named
+// operation in the query, or empty for anonymous operations.
+func (req *Request) label() string {
+	if req.opName != "" {
+		return req.opName
+	}
+	if req.operationName != "" {
+		return req.operationName
+	}
+	return firstOperationName(req.q)
+}
+
+// prepare returns an error if req cannot be sent. If req has no
+// endpoint, a copy of req with the default endpoint of the Client is
+// returned.
+func (c *Client) prepare(req *Request) (*Request, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+	if err := req.check(); err != nil {
+		return nil, err
+	}
+	req, err := req.resolveVarFuncs()
+	if err != nil {
+		return nil, err
+	}
+	if c.minifyQueries {
+		minified := *req
+		minified.q = minifyQuery(req.q)
+		req = &minified
+	}
+	if req.Endpoint != "" {
+		if err := ValidateEndpoint(req.Endpoint); err != nil {
+			return nil, err
+		}
+	} else {
+		if c.endpoint == "" {
+			return nil, errors.New("graphql: no endpoint set on the request or with WithEndpoint")
+		}
+		withEndpoint := *req
+		withEndpoint.Endpoint = c.endpoint
+		withEndpoint.defaultEndpoint = true
+		req = &withEndpoint
+	}
+	if err := c.validate(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// endpointOf returns the endpoint req is sent to.
+func (c *Client) endpointOf(req *Request) string {
+	if req.Endpoint != "" {
+		return req.Endpoint
+	}
+	return c.endpoint
+}
+
+// check returns an error if req cannot be sent.
+func (req *Request) check() error {
+	if req.err != nil {
+		return req.err
+	}
+	if err := checkFileFields(req.files); err != nil {
+		return err
+	}
+	if req.operationName == "" {
+		if n := operationCount(req.q); n > 1 {
+			return errors.Errorf("graphql: query contains %d operations, select one with OperationName", n)
+		}
+		return nil
+	}
+	if req.q != "" && selectedOperationType(req.q, req.operationName) == "" {
+		return errors.Errorf("graphql: query has no operation named %q", req.operationName)
+	}
+	return nil
+}
+
+// Var sets a variable. A variable is in one of three states:
+//
+//   - unset: it was never set, or removed with DelVar, and is omitted
+//     from the variables sent, so the server uses its default value;
+//   - null: it was set to nil with Var, or with VarNull, and is sent as
+//     an explicit null;
+//   - set: it was set to a value with Var and is sent as that value.
+//
+// Values are encoded following the rules of encoding/json, at any
+// depth: a []byte, for example, is sent as a base64 encoded string.
 func (req *Request) Var(key string, value interface{}) {
 	if req.vars == nil {
 		req.vars = make(map[string]interface{})
@@ -271,11 +1135,210 @@ func (req *Request) Var(key string, value interface{}) {
 	req.vars[key] = value
 }
 
+// VarNull sets a variable to an explicit null, which servers may treat
+// differently from a variable that is omitted, see Var.
+func (req *Request) VarNull(key string) {
+	req.Var(key, nil)
+}
+
+// DelVar removes a variable, so it is omitted from the variables sent
+// and the server uses its default value, see Var.
+func (req *Request) DelVar(key string) {
+	delete(req.vars, key)
+}
+
+// idempotencyKeyHeader is the header set by Request.IdempotencyKey.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKey sets the Idempotency-Key header to key, a unique value
+// such as a UUID that the server uses to recognise repeated requests
+// and execute them only once. Mutations are only retried by a Client
+// created with WithRetry when they have an idempotency key, and the
+// same key is sent with every attempt.
+func (req *Request) IdempotencyKey(key string) {
+	req.Header.Set(idempotencyKeyHeader, key)
+}
+
+// RedactVar marks the variable key as sensitive, such as a password or
+// personal data: its value is replaced with "***" wherever the Client
+// logs the variables of the request, but still sent to the server.
+func (req *Request) RedactVar(key string) {
+	if req.redacted == nil {
+		req.redacted = make(map[string]bool)
+	}
+	req.redacted[key] = true
+}
+
+// VarStruct sets a variable for every field of v, which must marshal
+// to a JSON object; the json tags of a struct are respected.
+// Variables are merged in the order they are set, so a variable set by
+// VarStruct replaces one set earlier by Var, and is replaced by a later
+// Var call with the same key. Fields marshaled as null are sent as an
+// explicit null, see VarNull; omit them with the omitempty option.
+// If v cannot be marshaled, the error is returned by Run.
+func (req *Request) VarStruct(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		req.err = errors.Wrap(err, "marshal variables")
+		return
+	}
+	var vars map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&vars); err != nil {
+		req.err = errors.Wrap(err, "marshal variables")
+		return
+	}
+	for key, value := range vars {
+		req.Var(key, value)
+	}
+}
+
+// VarMap sets a variable for every entry of m, as if Var was called for
+// each of them, so a key replaces a variable of the same name set
+// earlier. The values are not copied. A nil or empty m changes nothing.
+func (req *Request) VarMap(m map[string]interface{}) {
+	for key, value := range m {
+		req.Var(key, value)
+	}
+}
+
+// VarFunc sets a variable whose value is returned by fn, which is
+// called every time the request is run, right before the variables are
+// encoded. This gives control over how a value is rendered, for example
+// to send a time.Time as a Unix timestamp:
+//
+//	req.VarFunc("since", func() (interface{}, error) {
+//	    return since.Unix(), nil
+//	})
+//
+// If fn returns an error, the request fails without being sent.
+func (req *Request) VarFunc(key string, fn func() (interface{}, error)) {
+	req.Var(key, varFunc(fn))
+}
+
+// varFunc is the value of a variable set with VarFunc.
+type varFunc func() (interface{}, error)
+
+// resolveVarFuncs returns a copy of req with the variables set with
+// VarFunc replaced by their values, or req itself if it has none.
+func (req *Request) resolveVarFuncs() (*Request, error) {
+	var vars map[string]interface{}
+	for key, value := range req.vars {
+		fn, ok := value.(varFunc)
+		if !ok {
+			continue
+		}
+		if vars == nil {
+			vars = make(map[string]interface{}, len(req.vars))
+			for k, v := range req.vars {
+				vars[k] = v
+			}
+		}
+		v, err := fn()
+		if err != nil {
+			return nil, errors.Wrapf(err, "variable %s", key)
+		}
+		vars[key] = v
+	}
+	if vars == nil {
+		return req, nil
+	}
+	resolved := *req
+	resolved.vars = vars
+	return &resolved, nil
+}
+
+// CaptureResponseHeaders makes Run store the headers of the response in
+// h, such as rate limit or cache status headers. The headers are stored
+// as soon as a response is received, so they are available even when
+// Run returns an error for the status code or body of the response.
+// Responses shared with WithSingleFlight or WithResponseCache only store
+// headers for the call that sent the request.
+func (req *Request) CaptureResponseHeaders(h *http.Header) {
+	req.responseHeader = h
+}
+
+// captureHeader stores the headers of res as set with
+// CaptureResponseHeaders.
+func (req *Request) captureHeader(res *http.Response) {
+	if req.responseHeader != nil {
+		*req.responseHeader = res.Header.Clone()
+	}
+}
+
+// ResetVars removes all variables of the request, so it can be reused
+// with new ones. Files are kept.
+func (req *Request) ResetVars() {
+	req.vars = nil
+}
+
+// Clone returns a copy of the request that can be changed and run
+// independently of req, for example from another goroutine. The
+// variables and headers are copied, but variable values themselves are
+// shared, so maps or slices set as values must not be modified.
+// The destinations set with ResponseExtensions and
+// CaptureResponseHeaders are not copied.
+func (req *Request) Clone() *Request {
+	clone := *req
+	clone.extensions = nil
+	clone.responseHeader = nil
+	if req.vars != nil {
+		clone.vars = make(map[string]interface{}, len(req.vars))
+		for key, value := range req.vars {
+			clone.vars[key] = value
+		}
+	}
+	if req.redacted != nil {
+		clone.redacted = make(map[string]bool, len(req.redacted))
+		for key := range req.redacted {
+			clone.redacted[key] = true
+		}
+	}
+	if req.typenames != nil {
+		clone.typenames = make(map[string][]string, len(req.typenames))
+		for path, typenames := range req.typenames {
+			clone.typenames[path] = append([]string(nil), typenames...)
+		}
+	}
+	clone.Header = req.Header.Clone()
+	if req.files != nil {
+		clone.files = append([]File(nil), req.files...)
+	}
+	return &clone
+}
+
 // Vars gets the variables for this Request.
 func (req *Request) Vars() map[string]interface{} {
 	return req.vars
 }
 
+// ResponseExtensions sets v as the destination for the extensions field
+// of the response, such as tracing or cost information. v must be a
+// pointer, for example to a map[string]interface{}. The extensions are
+// unmarshaled even when the response contains errors.
+func (req *Request) ResponseExtensions(v interface{}) {
+	req.extensions = v
+}
+
+// WithHTTPClient makes Run send the request with httpClient instead of
+// the http.Client of the Client, for example to allow a long-running
+// request more time than the others. Other requests are not affected.
+// httpClient is used as is: the options of the Client that change its
+// http.Client, such as WithTransport, WithConnectionPool and
+// WithCookieJar, are not applied to it.
+func (req *Request) WithHTTPClient(httpClient *http.Client) {
+	req.httpClient = httpClient
+}
+
+// httpClientFor returns the http.Client that sends req.
+func (c *Client) httpClientFor(req *Request) *http.Client {
+	if req.httpClient != nil {
+		return req.httpClient
+	}
+	return c.httpClient
+}
+
 // Files gets the files in this request.
 func (req *Request) Files() []File {
 	return req.files
@@ -287,6 +1350,8 @@ func (req *Request) Query() string {
 }
 
 // File sets a file to upload.
+// Every file needs a field name of its own; Run returns an error if
+// two files share one.
 // Files are only supported with a Client that was created with
 // the UseMultipartForm option.
 func (req *Request) File(fieldname, filename string, r io.Reader) {
@@ -297,9 +1362,88 @@ func (req *Request) File(fieldname, filename string, r io.Reader) {
 	})
 }
 
+// FileVar sets a file to upload as the value of the variable at path,
+// for example "variables.input.avatar", following the GraphQL multipart
+// request specification.
+// Files are only supported with a Client that was created with
+// the UseMultipartForm option.
+func (req *Request) FileVar(path, filename string, r io.Reader) {
+	req.files = append(req.files, File{
+		Path: path,
+		Name: filename,
+		R:    r,
+	})
+}
+
+// AppendFileVar adds a file to upload to the list variable at path, such
+// as a [Upload!] variable. Each call binds the file to the next index of
+// the list, so
+//
+//	req.AppendFileVar("variables.files", "a.png", a)
+//	req.AppendFileVar("variables.files", "b.png", b)
+//
+// is the same as binding the files to "variables.files.0" and
+// "variables.files.1" with FileVar.
+func (req *Request) AppendFileVar(path, filename string, r io.Reader) {
+	var n int
+	for i := range req.files {
+		if strings.HasPrefix(req.files[i].Path, path+".") {
+			n++
+		}
+	}
+	req.FileVar(path+"."+strconv.Itoa(n), filename, r)
+}
+
+// AddFile adds a file to upload. Use it instead of File or FileVar to
+// set every property of the file, such as its content type.
+// Files are only supported with a Client that was created with
+// the UseMultipartForm option.
+func (req *Request) AddFile(f File) {
+	req.files = append(req.files, f)
+}
+
+// FileFromOS adds f as a file to upload, named after the base name of
+// f. If fieldOrPath starts with "variables.", the file is bound to the
+// variable at that path as with FileVar, otherwise it is sent in the
+// form field fieldOrPath as with File. If f is a regular file, the
+// size of the rest of the file is sent as the Content-Length of its
+// part.
+func (req *Request) FileFromOS(fieldOrPath string, f *os.File) {
+	file := File{
+		Name: filepath.Base(f.Name()),
+		R:    f,
+	}
+	if strings.HasPrefix(fieldOrPath, "variables.") {
+		file.Path = fieldOrPath
+	} else {
+		file.Field = fieldOrPath
+	}
+	if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+		if offset, err := f.Seek(0, io.SeekCurrent); err == nil {
+			file.Size = info.Size() - offset
+		}
+	}
+	req.AddFile(file)
+}
+
 // File represents a file to upload.
 type File struct {
+	// Field is the name of the form field of the file. It is ignored
+	// when Path is set.
 	Field string
 	Name  string
 	R     io.Reader
+
+	// ContentType is the Content-Type of the file's part. If it is
+	// empty, it is detected from the first 512 bytes of the file with
+	// http.DetectContentType.
+	ContentType string
+
+	// Path is the object path of the variable the file is bound to,
+	// see Request.FileVar.
+	Path string
+
+	// Size is the number of bytes that R yields. If it is greater than
+	// zero, it is sent as the Content-Length of the file's part.
+	Size int64
 }
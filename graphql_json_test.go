@@ -1,11 +1,17 @@
 package graphql
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -126,3 +132,486 @@ func TestHeader(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(calls, 1)
 }
+
+func TestHeaderOverridesContentType(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header["Content-Type"], []string{"application/graphql+json"})
+		is.Equal(r.Header.Get("X-Request-Id"), "abc")
+
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+
+	req := NewRequest("query {}", srv.URL)
+	req.Header.Set("Content-Type", "application/graphql+json")
+	req.Header.Set("X-Request-Id", "abc")
+
+	var resp struct {
+		Value string
+	}
+	err := client.Run(ctx, req, &resp)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+
+	// the request headers are left untouched
+	is.Equal(len(req.Header), 2)
+}
+
+func TestGraphQLError(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, `{
+			"data": {"value": "partial"},
+			"errors": [
+				{
+					"message": "not allowed",
+					"locations": [{"line": 1, "column": 9}],
+					"path": ["secret", 0],
+					"extensions": {"code": "FORBIDDEN"}
+				},
+				{"message": "second"}
+			]
+		}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+
+	var resp struct {
+		Value string
+	}
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), &resp)
+	is.Equal(err.Error(), "graphql: not allowed (and 1 more errors)")
+	var gqlErr *GraphQLError
+	is.True(errors.As(err, &gqlErr))
+	is.Equal(len(gqlErr.Errors), 2)
+	is.Equal(gqlErr.Errors[0].Message, "not allowed")
+	is.Equal(gqlErr.Errors[0].Locations, []ErrorLocation{{Line: 1, Column: 9}})
+	is.Equal(gqlErr.Errors[0].Path, []interface{}{"secret", float64(0)})
+	is.Equal(gqlErr.Errors[0].Extensions["code"], "FORBIDDEN")
+	is.Equal(gqlErr.Errors[1].Message, "second")
+	is.Equal(resp.Value, "partial") // data is still unmarshaled
+}
+
+func TestResponseExtensions(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, `{
+			"errors": [{"message": "too expensive"}],
+			"extensions": {"cost": {"requested": 1200, "limit": 1000}}
+		}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+
+	req := NewRequest("query {}", srv.URL)
+	var ext struct {
+		Cost struct {
+			Requested int
+			Limit     int
+		}
+	}
+	req.ResponseExtensions(&ext)
+	err := client.Run(ctx, req, nil)
+	is.Equal(err.Error(), "graphql: too expensive")
+	is.Equal(ext.Cost.Requested, 1200)
+	is.Equal(ext.Cost.Limit, 1000)
+}
+
+func TestWithTimeout(t *testing.T) {
+	is := is.New(t)
+
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}),
+	}
+	client := NewClient(WithHTTPClient(testClient), WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	err := client.Run(context.Background(), NewRequest("query {}", "http://example.com/graphql"), nil)
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.True(time.Since(start) < time.Second)
+
+	// a deadline on the context is not extended
+	client = NewClient(WithHTTPClient(testClient), WithTimeout(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), nil)
+	is.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestOperationName(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		is.Equal(string(b), `{"query":"query A { a } query B { b }","operationName":"B","variables":null}`+"\n")
+		_, err = io.WriteString(w, `{"data":{"b":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+
+	req := NewRequest("query A { a } query B { b }", srv.URL)
+	err := client.Run(ctx, req, nil)
+	is.Equal(err.Error(), "graphql: query contains 2 operations, select one with OperationName")
+	is.Equal(calls, 0)
+
+	req.OperationName("C")
+	err = client.Run(ctx, req, nil)
+	is.Equal(err.Error(), `graphql: query has no operation named "C"`)
+	is.Equal(calls, 0)
+
+	req.OperationName("B")
+	err = client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestStrictNoData(t *testing.T) {
+	is := is.New(t)
+
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithStrictNoData())
+	for _, body = range []string{`{}`, `{"data":null}`} {
+		var resp map[string]interface{}
+		err := client.Run(ctx, NewRequest("query {}", srv.URL), &resp)
+		is.Equal(err, ErrNoData) // body
+	}
+
+	body = `{"data":null,"errors":[{"message":"not found"}]}`
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.Equal(err.Error(), "graphql: not found")
+
+	body = `{"data":{}}`
+	err = client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+
+	// without the option missing data is not an error
+	body = `{}`
+	err = NewClient().Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+}
+
+func TestStrictResponseDecoding(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"user":{"name":"matryer","email":"mat@example.com"}},"extensions":{"cost":1}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var resp struct {
+		User struct {
+			Name string
+		}
+	}
+	err := NewClient().Run(ctx, NewRequest("query {}", srv.URL), &resp)
+	is.NoErr(err)
+	is.Equal(resp.User.Name, "matryer")
+
+	err = NewClient(WithStrictResponseDecoding()).Run(ctx, NewRequest("query {}", srv.URL), &resp)
+	is.Equal(err.Error(), `decoding response: json: unknown field "email"`)
+}
+
+func TestWithEndpoint(t *testing.T) {
+	is := is.New(t)
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithEndpoint(srv.URL + "/default"))
+	req := NewRequest("query {}", "")
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(req.Endpoint, "") // the request is not modified
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL+"/other"), nil))
+	is.Equal(paths, []string{"/default", "/other"})
+
+	err := NewClient().Run(ctx, NewRequest("query {}", ""), nil)
+	is.Equal(err.Error(), "graphql: no endpoint set on the request or with WithEndpoint")
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	is := is.New(t)
+	for endpoint, want := range map[string]string{
+		"":                     "graphql: endpoint is empty",
+		"/graphql":             `graphql: invalid endpoint "/graphql": scheme must be http or https`,
+		"example.com/graphql":  `graphql: invalid endpoint "example.com/graphql": scheme must be http or https`,
+		"ftp://example.com":    `graphql: invalid endpoint "ftp://example.com": scheme must be http or https`,
+		"http:///graphql":      `graphql: invalid endpoint "http:///graphql": missing host`,
+		"http://a b.com/":      `graphql: invalid endpoint "http://a b.com/": parse "http://a b.com/": invalid character " " in host name`,
+		"https://example.com/": "",
+	} {
+		err := ValidateEndpoint(endpoint)
+		if want == "" {
+			is.NoErr(err)
+			continue
+		}
+		is.Equal(err.Error(), want) // endpoint
+	}
+
+	ctx := context.Background()
+	err := NewClient().Run(ctx, NewRequest("query {}", "example.com/graphql"), nil)
+	is.Equal(err.Error(), `graphql: invalid endpoint "example.com/graphql": scheme must be http or https`)
+
+	// an invalid default endpoint fails every request
+	client := NewClient(WithEndpoint("/graphql"))
+	err = client.Run(ctx, NewRequest("query {}", "https://example.com/graphql"), nil)
+	is.Equal(err.Error(), `graphql: invalid endpoint "/graphql": scheme must be http or https`)
+}
+
+func TestRawMessageResponse(t *testing.T) {
+	is := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"thing": {"a" : 1, "b":[1, 2.50]}, "other":null}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	var resp struct {
+		Thing json.RawMessage
+	}
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), &resp))
+	is.Equal(string(resp.Thing), `{"a" : 1, "b":[1, 2.50]}`)
+
+	var raw json.RawMessage
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), &raw))
+	is.Equal(string(raw), `{"thing": {"a" : 1, "b":[1, 2.50]}, "other":null}`)
+}
+
+func TestPartialData(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{
+			"data": {"user": {"name": "matryer", "email": null}},
+			"errors": [{"message": "not allowed", "path": ["user", "email"]}]
+		}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{NewClient(), NewClient(UseMultipartForm())} {
+		var resp struct {
+			User struct {
+				Name  string
+				Email *string
+			}
+		}
+		err := client.Run(ctx, NewRequest("query { user { name email } }", srv.URL), &resp)
+		var gqlErr *GraphQLError
+		is.True(errors.As(err, &gqlErr))
+		is.Equal(gqlErr.Errors[0].Path, []interface{}{"user", "email"})
+		is.Equal(resp.User.Name, "matryer")
+		is.Equal(resp.User.Email, nil)
+	}
+}
+
+func TestWithMinifyQueries(t *testing.T) {
+	is := is.New(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		body = string(b)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithMinifyQueries())
+	req := NewRequest(`
+		# find items
+		query ($text: String = "a  b") {
+			search(text: $text) {
+				id
+				name
+			}
+		}
+	`, srv.URL)
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(body, `{"query":"query($text:String=\"a  b\"){search(text:$text){id name}}","variables":null}`+"\n")
+	is.True(strings.HasPrefix(req.Query(), "\n\t\t# find items")) // the request is not modified
+}
+
+func TestCaptureResponseHeaders(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		if r.URL.Path == "/limited" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	var header http.Header
+	req := NewRequest("query {}", srv.URL)
+	req.CaptureResponseHeaders(&header)
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(header.Get("X-RateLimit-Remaining"), "41")
+
+	req = NewRequest("query {}", srv.URL+"/limited")
+	req.CaptureResponseHeaders(&header)
+	err := client.Run(ctx, req, nil)
+	is.True(IsHTTPError(err))
+	is.Equal(header.Get("X-RateLimit-Remaining"), "0") // set on errors too
+}
+
+func TestRunConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string
+			Variables struct {
+				ID int
+			}
+		}
+		switch {
+		case r.Method == http.MethodGet:
+			body.Query = r.URL.Query().Get("query")
+			json.Unmarshal([]byte(r.URL.Query().Get("variables")), &body.Variables)
+		case strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/"):
+			body.Query = r.FormValue("query")
+			json.Unmarshal([]byte(r.FormValue("variables")), &body.Variables)
+		case r.Header.Get("Content-Encoding") == "gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			json.NewDecoder(zr).Decode(&body)
+		default:
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		if body.Query == "" {
+			io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"id":%d}}`, body.Variables.ID)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+	}{
+		{"default", nil},
+		{"get", []ClientOption{UseGETForQueries(), WithPersistedQueries()}},
+		{"multipart", []ClientOption{UseMultipartForm()}},
+		{"features", []ClientOption{
+			WithPersistedQueries(),
+			WithResponseCache(time.Minute, 10),
+			WithSingleFlight(),
+			WithRetry(2, nil),
+			WithStreamingResponses(),
+			WithRequestCompression(1),
+			WithHeaders(http.Header{"X-Test": {"1"}}),
+			WithMetrics(func(RequestMetrics) {}),
+			WithAfterResponse(func(context.Context, *http.Response) error { return nil }),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			client := NewClient(tt.opts...)
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					req := NewRequest("query ($id: Int!) { id }", srv.URL)
+					req.Var("id", i%20)
+					var resp struct {
+						ID int
+					}
+					is.NoErr(client.Run(ctx, req, &resp))
+					is.Equal(resp.ID, i%20)
+				}(i)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestRunString(t *testing.T) {
+	is := is.New(t)
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Authorization"), "Bearer token")
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		bodies = append(bodies, string(b))
+		io.WriteString(w, `{"data":{"user":{"name":"Mat"}}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(WithEndpoint(srv.URL), WithBearerToken("token"))
+	const query = "query ($id: ID!) { user(id: $id) { name } }"
+
+	var resp struct {
+		User struct {
+			Name string
+		}
+	}
+	is.NoErr(client.RunString(ctx, "", query, map[string]interface{}{"id": "1"}, &resp))
+	is.Equal(resp.User.Name, "Mat")
+
+	req := NewRequest(query, srv.URL)
+	req.Var("id", "1")
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(len(bodies), 2)
+	is.Equal(bodies[0], bodies[1]) // same request as the long form
+}
@@ -2,12 +2,20 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/matryer/is"
@@ -29,7 +37,7 @@ func TestWithClient(t *testing.T) {
 	ctx := context.Background()
 	client := NewClient(WithHTTPClient(testClient), UseMultipartForm())
 
-	req := NewRequest(``, "")
+	req := NewRequest(``, "http://example.com/graphql")
 	client.Run(ctx, req, nil)
 
 	is.Equal(calls, 1) // calls
@@ -200,8 +208,440 @@ func TestFile(t *testing.T) {
 	is.NoErr(err)
 }
 
+func TestFileFromOS(t *testing.T) {
+	is := is.New(t)
+	f, err := ioutil.TempFile(t.TempDir(), "upload-*.txt")
+	is.NoErr(err)
+	defer f.Close()
+	_, err = f.WriteString("This is a file")
+	is.NoErr(err)
+	_, err = f.Seek(0, io.SeekStart)
+	is.NoErr(err)
+	g, err := os.Open(f.Name())
+	is.NoErr(err)
+	defer g.Close()
+	_, err = g.Seek(5, io.SeekStart)
+	is.NoErr(err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		is.NoErr(err)
+		defer file.Close()
+		is.Equal(header.Filename, filepath.Base(f.Name()))
+		is.Equal(header.Header.Get("Content-Length"), "14")
+		is.Equal(header.Size, int64(14))
+
+		_, header, err = r.FormFile("0")
+		is.NoErr(err)
+		is.Equal(header.Header.Get("Content-Length"), "9") // the rest of the file
+		is.Equal(header.Size, int64(9))
+		is.Equal(r.FormValue("map"), `{"0":["variables.file"]}`+"\n")
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	req.FileFromOS("file", f)
+	req.FileFromOS("variables.file", g)
+	is.NoErr(client.Run(ctx, req, nil))
+}
+
+func TestFileDuplicateField(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(UseMultipartForm())
+
+	req := NewRequest("query {}", srv.URL)
+	req.File("file", "a.txt", strings.NewReader("a"))
+	req.FileVar("variables.file", "b.txt", strings.NewReader("b"))
+	req.AddFile(File{Field: "file", Name: "c.txt", R: strings.NewReader("c")})
+	err := client.Run(ctx, req, nil)
+	is.Equal(err.Error(), `graphql: duplicate file field "file"`)
+	is.Equal(calls, 0) // nothing is sent
+
+	req = NewRequest("query {}", srv.URL)
+	req.File("file", "a.txt", strings.NewReader("a"))
+	req.File("other", "b.txt", strings.NewReader("b"))
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(calls, 1)
+}
+
+func TestHeaderMultipart(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("X-Custom-Header"), "123")
+		is.True(strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data"))
+
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(UseMultipartForm())
+
+	req := NewRequest("query {}", srv.URL)
+	req.Header.Set("X-Custom-Header", "123")
+
+	var responseData map[string]interface{}
+	err := client.Run(ctx, req, &responseData)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestFileVar(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.FormValue("operations"), `{"query":"mutation ($input: Input!) {}","variables":{"input":{"avatar":null,"name":"matryer"}}}`+"\n")
+		is.Equal(r.FormValue("map"), `{"0":["variables.input.avatar"]}`+"\n")
+		is.Equal(r.FormValue("query"), "")
+		file, header, err := r.FormFile("0")
+		is.NoErr(err)
+		defer file.Close()
+		is.Equal(header.Filename, "avatar.png")
+		b, err := ioutil.ReadAll(file)
+		is.NoErr(err)
+		is.Equal(string(b), `This is a file`)
+
+		_, err = io.WriteString(w, `{"data":{"value":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation ($input: Input!) {}", srv.URL)
+	input := map[string]interface{}{"name": "matryer"}
+	req.Var("input", input)
+	req.FileVar("variables.input.avatar", "avatar.png", strings.NewReader(`This is a file`))
+	err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+	is.Equal(input, map[string]interface{}{"name": "matryer"}) // variables are not modified
+}
+
+func TestAppendFileVar(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.FormValue("operations"), `{"query":"mutation ($files: [Upload!]!) {}","variables":{"files":[null,null,null]}}`+"\n")
+		is.Equal(r.FormValue("map"), `{"0":["variables.files.0"],"1":["variables.files.1"],"2":["variables.files.2"]}`+"\n")
+		for i, name := range []string{"a.png", "b.png", "c.png"} {
+			file, header, err := r.FormFile(strconv.Itoa(i))
+			is.NoErr(err)
+			is.Equal(header.Filename, name)
+			b, err := ioutil.ReadAll(file)
+			is.NoErr(err)
+			is.Equal(string(b), "contents of "+name)
+			file.Close()
+		}
+
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation ($files: [Upload!]!) {}", srv.URL)
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		req.AppendFileVar("variables.files", name, strings.NewReader("contents of "+name))
+	}
+	err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestOperationNameMultipart(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.FormValue("query"), "query A { a } query B { b }")
+		is.Equal(r.FormValue("operationName"), "A")
+		_, err := io.WriteString(w, `{"data":{"a":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("query A { a } query B { b }", srv.URL)
+	req.OperationName("A")
+	err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestOperationNameFileVar(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.FormValue("operations"), `{"query":"mutation A($f: Upload!) { a(f: $f) } mutation B($f: Upload!) { b(f: $f) }","operationName":"B","variables":{"f":null}}`+"\n")
+		_, err := io.WriteString(w, `{"data":{"b":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation A($f: Upload!) { a(f: $f) } mutation B($f: Upload!) { b(f: $f) }", srv.URL)
+	req.OperationName("B")
+	req.FileVar("variables.f", "file.txt", strings.NewReader("contents"))
+	err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
+func TestFileFields(t *testing.T) {
+	is := is.New(t)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		for _, want := range []struct {
+			field, filename, contentType, contents string
+		}{
+			{"avatar", "avatar.png", "image/png", "avatar image"},
+			{"banner", `my "banner".txt`, "text/plain; charset=utf-8", "banner text"},
+			{"logo", "logo.png", "image/png", "\x89PNG\r\n\x1a\nlogo image"},
+		} {
+			file, header, err := r.FormFile(want.field)
+			is.NoErr(err)
+			is.Equal(header.Filename, want.filename)
+			is.Equal(header.Header.Get("Content-Type"), want.contentType)
+			b, err := ioutil.ReadAll(file)
+			is.NoErr(err)
+			is.Equal(string(b), want.contents)
+			file.Close()
+		}
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		is.NoErr(err)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation {}", srv.URL)
+	req.AddFile(File{
+		Field:       "avatar",
+		Name:        "avatar.png",
+		R:           strings.NewReader("avatar image"),
+		ContentType: "image/png",
+	})
+	req.File("banner", `my "banner".txt`, strings.NewReader("banner text"))
+	req.File("logo", "logo.png", strings.NewReader("\x89PNG\r\n\x1a\nlogo image"))
+	err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(calls, 1)
+}
+
 type roundTripperFunc func(req *http.Request) (*http.Response, error)
 
 func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return fn(req)
 }
+
+func TestMultipartStreamsFiles(t *testing.T) {
+	is := is.New(t)
+	const size = 8 * 1024 * 1024
+	file := &atomicCountingReader{r: io.LimitReader(zeroReader{}, size)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		is.NoErr(err)
+		var received int64
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			is.NoErr(err)
+			if part.FormName() != "0" {
+				continue
+			}
+			buf := make([]byte, 1024)
+			n, err := io.ReadFull(part, buf)
+			is.NoErr(err)
+			is.True(atomic.LoadInt64(&file.n) < size/2) // the file is not read before it is sent
+			m, err := io.Copy(ioutil.Discard, part)
+			is.NoErr(err)
+			received = int64(n) + m
+		}
+		is.Equal(received, int64(size))
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	req.FileVar("variables.file", "large.bin", file)
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(atomic.LoadInt64(&file.n), int64(size))
+}
+
+func TestMultipartStreamedFileError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	failing := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("stream broken")))
+	req.FileVar("variables.file", "broken.bin", failing)
+	err := client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "stream broken"))
+}
+
+// zeroReader is an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// atomicCountingReader counts the bytes read from it, safe for
+// concurrent use.
+type atomicCountingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *atomicCountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+func TestMultipartCancel(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+	}))
+	defer srv.Close()
+	client := NewClient(UseMultipartForm())
+	release := make(chan struct{})
+
+	for _, r := range []io.Reader{
+		slowReader{},
+		blockingReader{release: release},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		req := NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+		req.FileVar("variables.file", "file.bin", r)
+		start := time.Now()
+		err := client.Run(ctx, req, nil)
+		is.True(errors.Is(err, context.Canceled))
+		is.True(time.Since(start) < time.Second) // Run returns once ctx is done
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for bodyWriters() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	is.Equal(bodyWriters(), 0) // no goroutine leaks
+}
+
+// slowReader yields a byte every millisecond, forever.
+type slowReader struct{}
+
+func (slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return copy(p, "x"), nil
+}
+
+// blockingReader blocks until release is closed.
+type blockingReader struct {
+	release chan struct{}
+}
+
+func (r blockingReader) Read(p []byte) (int, error) {
+	<-r.release
+	return 0, io.EOF
+}
+
+// bodyWriters returns the number of goroutines writing multipart
+// request bodies.
+func bodyWriters() int {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	return strings.Count(string(buf[:n]), "graphql.(*pipeBody).Read.func")
+}
+
+func TestWithMultipartBoundary(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(UseMultipartForm(), WithMultipartBoundary("test-boundary"))
+	req := NewRequest("mutation ($a: Upload!, $b: Upload!) { upload(a: $a, b: $b) }", "https://example.com/graphql")
+	req.FileVar("variables.a", "a.txt", strings.NewReader("first"))
+	req.FileVar("variables.b", "b.txt", strings.NewReader("second"))
+	r, err := client.BuildRequest(context.Background(), req)
+	is.NoErr(err)
+	is.Equal(r.Header.Get("Content-Type"), "multipart/form-data; boundary=test-boundary")
+	b, err := ioutil.ReadAll(r.Body)
+	is.NoErr(err)
+	is.Equal(string(b), "--test-boundary\r\n"+
+		"Content-Disposition: form-data; name=\"operations\"\r\n\r\n"+
+		`{"query":"mutation ($a: Upload!, $b: Upload!) { upload(a: $a, b: $b) }","variables":{"a":null,"b":null}}`+"\n"+
+		"\r\n--test-boundary\r\n"+
+		"Content-Disposition: form-data; name=\"map\"\r\n\r\n"+
+		`{"0":["variables.a"],"1":["variables.b"]}`+"\n"+
+		"\r\n--test-boundary\r\n"+
+		"Content-Disposition: form-data; name=\"0\"; filename=\"a.txt\"\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n"+
+		"first"+
+		"\r\n--test-boundary\r\n"+
+		"Content-Disposition: form-data; name=\"1\"; filename=\"b.txt\"\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n"+
+		"second"+
+		"\r\n--test-boundary--\r\n")
+
+	client = NewClient(UseMultipartForm(), WithMultipartBoundary("invalid boundary "))
+	err = client.Run(context.Background(), req, nil)
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), "graphql: multipart boundary: "))
+}
+
+func TestFileMapOrder(t *testing.T) {
+	is := is.New(t)
+	var files fileMap
+	for i := 0; i < 11; i++ {
+		files = append(files, []string{"variables.files." + strconv.Itoa(i)})
+	}
+	b, err := json.Marshal(files)
+	is.NoErr(err)
+	is.True(strings.HasSuffix(string(b), `"9":["variables.files.9"],"10":["variables.files.10"]}`))
+}
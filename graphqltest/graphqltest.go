@@ -0,0 +1,175 @@
+// Package graphqltest provides a GraphQL server returning canned
+// responses, for testing code that uses the graphql client.
+//
+//	srv := graphqltest.NewServer(graphqltest.Response{
+//		Match: "GetUser",
+//		Data:  map[string]interface{}{"user": map[string]interface{}{"name": "Mat"}},
+//	})
+//	defer srv.Close()
+//	client := graphql.NewClient(graphql.WithEndpoint(srv.URL))
+package graphqltest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/donutloop/graphql"
+)
+
+// Response is a canned response of a Server.
+type Response struct {
+	// Match selects the requests the response is returned for: those
+	// whose operation name equals Match or whose query contains it.
+	// An empty Match matches every request.
+	Match string
+	// Data is encoded as the data field of the response.
+	Data interface{}
+	// Errors are returned in the errors field of the response.
+	Errors []graphql.ErrorEntry
+	// StatusCode is the status code of the response, 200 if zero.
+	StatusCode int
+}
+
+// matches reports whether the response is returned for req.
+func (r Response) matches(req Request) bool {
+	return r.Match == "" || r.Match == req.OperationName || strings.Contains(req.Query, r.Match)
+}
+
+// Request is a request received by a Server.
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	Header        http.Header
+}
+
+// Server is a GraphQL server for tests. It answers every request with
+// the first of its responses that matches it, and records the requests
+// it receives. Requests sent as JSON, as GET requests and as multipart
+// forms are understood.
+type Server struct {
+	// URL is the endpoint of the server.
+	URL string
+
+	srv       *httptest.Server
+	responses []Response
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// NewServer starts a Server returning the given responses. The caller
+// should call Close when finished.
+func NewServer(responses ...Response) *Server {
+	s := &Server{responses: responses}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Requests returns the requests received by the server so far, in the
+// order they arrived.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+	resp := Response{
+		Errors: []graphql.ErrorEntry{{Message: fmt.Sprintf("graphqltest: no response for query %q", req.Query)}},
+	}
+	for _, candidate := range s.responses {
+		if candidate.matches(req) {
+			resp = candidate
+			break
+		}
+	}
+	body := struct {
+		Data   interface{}          `json:"data,omitempty"`
+		Errors []graphql.ErrorEntry `json:"errors,omitempty"`
+	}{
+		Data:   resp.Data,
+		Errors: resp.Errors,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if resp.StatusCode != 0 {
+		w.WriteHeader(resp.StatusCode)
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+// parseRequest reads the GraphQL request sent in r.
+func parseRequest(r *http.Request) (Request, error) {
+	var body struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	switch {
+	case r.Method == http.MethodGet:
+		params := r.URL.Query()
+		body.Query = params.Get("query")
+		body.OperationName = params.Get("operationName")
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &body.Variables); err != nil {
+				return Request{}, fmt.Errorf("decoding variables: %v", err)
+			}
+		}
+	case strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data"):
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return Request{}, fmt.Errorf("parsing form: %v", err)
+		}
+		if operations := r.FormValue("operations"); operations != "" {
+			if err := json.Unmarshal([]byte(operations), &body); err != nil {
+				return Request{}, fmt.Errorf("decoding operations: %v", err)
+			}
+			break
+		}
+		body.Query = r.FormValue("query")
+		body.OperationName = r.FormValue("operationName")
+		if variables := r.FormValue("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &body.Variables); err != nil {
+				return Request{}, fmt.Errorf("decoding variables: %v", err)
+			}
+		}
+	default:
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				return Request{}, fmt.Errorf("decompressing body: %v", err)
+			}
+			defer gr.Close()
+			reader = gr
+		}
+		if err := json.NewDecoder(reader).Decode(&body); err != nil {
+			return Request{}, fmt.Errorf("decoding body: %v", err)
+		}
+	}
+	return Request{
+		Query:         body.Query,
+		OperationName: body.OperationName,
+		Variables:     body.Variables,
+		Header:        r.Header.Clone(),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package graphqltest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/donutloop/graphql"
+	"github.com/matryer/is"
+)
+
+func TestServer(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer(
+		Response{
+			Match: "GetUser",
+			Data:  map[string]interface{}{"user": map[string]interface{}{"name": "Mat"}},
+		},
+		Response{
+			Match:  "deleteUser",
+			Errors: []graphql.ErrorEntry{{Message: "forbidden"}},
+		},
+	)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient(graphql.WithEndpoint(srv.URL))
+	req := graphql.NewRequest("query GetUser($id: ID!) { user(id: $id) { name } }", "")
+	req.Var("id", "1")
+	req.Header.Set("X-Test", "yes")
+	var resp struct {
+		User struct{ Name string }
+	}
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(resp.User.Name, "Mat")
+
+	err := client.Run(ctx, graphql.NewRequest(`mutation { deleteUser(id: "1") }`, ""), nil)
+	is.Equal(err.Error(), "graphql: forbidden")
+
+	err = client.Run(ctx, graphql.NewRequest("query { other }", ""), nil)
+	is.Equal(err.Error(), `graphql: graphqltest: no response for query "query { other }"`)
+
+	requests := srv.Requests()
+	is.Equal(len(requests), 3)
+	is.Equal(requests[0].OperationName, "")
+	is.Equal(requests[0].Variables, map[string]interface{}{"id": "1"})
+	is.Equal(requests[0].Header.Get("X-Test"), "yes")
+	is.Equal(requests[1].Query, `mutation { deleteUser(id: "1") }`)
+}
+
+func TestServerTransports(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer(Response{Data: map[string]interface{}{"ok": true}})
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, opt := range []graphql.ClientOption{
+		graphql.UseGETForQueries(),
+		graphql.UseMultipartForm(),
+		graphql.WithRequestCompression(0),
+	} {
+		client := graphql.NewClient(graphql.WithEndpoint(srv.URL), opt)
+		req := graphql.NewRequest("query Check($n: Int) { ok }", "")
+		req.OperationName("Check")
+		req.Var("n", 1)
+		var resp struct{ OK bool }
+		is.NoErr(client.Run(ctx, req, &resp))
+		is.True(resp.OK)
+	}
+	for _, req := range srv.Requests() {
+		is.Equal(req.OperationName, "Check")
+		is.Equal(req.Variables, map[string]interface{}{"n": float64(1)})
+	}
+}
+
+func TestServerStatusCode(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer(Response{StatusCode: 503})
+	defer srv.Close()
+
+	client := graphql.NewClient(graphql.WithEndpoint(srv.URL))
+	err := client.Run(context.Background(), graphql.NewRequest("query {}", ""), nil)
+	is.True(graphql.IsHTTPError(err))
+}
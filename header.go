@@ -0,0 +1,72 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithHeaders sets headers that are sent with every request, such as a
+// tenant ID or an API key. When used more than once, the headers are
+// merged. A header set on a Request, with WithContextHeader or by an
+// authentication option takes precedence.
+// h is copied, so changing it afterwards does not affect the Client.
+func WithHeaders(h http.Header) ClientOption {
+	return func(client *Client) {
+		if client.header == nil {
+			client.header = make(http.Header)
+		}
+		copyHeader(client.header, h)
+	}
+}
+
+// contextHeader is a header whose value is read from the context of a
+// request, see WithContextHeader.
+type contextHeader struct {
+	name string
+	key  interface{}
+}
+
+// WithContextHeader sets the header name on every request to the value
+// stored in the context of the request under key, which makes it easy
+// to pass on values such as correlation IDs:
+//
+//	client := graphql.NewClient(graphql.WithContextHeader("X-Request-ID", requestIDKey{}))
+//	ctx = context.WithValue(ctx, requestIDKey{}, "f3b1c2")
+//
+// The header is omitted when the context has no value for key or the
+// value is not a string. A header set on a Request takes precedence.
+func WithContextHeader(name string, key interface{}) ClientOption {
+	return func(client *Client) {
+		client.contextHeaders = append(client.contextHeaders, contextHeader{name: name, key: key})
+	}
+}
+
+// setContextHeaders sets the headers configured with WithContextHeader
+// on header from the values in ctx.
+func (c *Client) setContextHeaders(ctx context.Context, header http.Header) {
+	for _, h := range c.contextHeaders {
+		if value, ok := ctx.Value(h.key).(string); ok {
+			header.Set(h.name, value)
+		}
+	}
+}
+
+// DefaultUserAgent is the User-Agent header sent with requests unless
+// changed with WithUserAgent.
+const DefaultUserAgent = "donutloop-graphql/1.0"
+
+// WithUserAgent sets the User-Agent header sent with every request.
+// An empty ua suppresses the header entirely. A User-Agent header set on
+// a Request takes precedence.
+func WithUserAgent(ua string) ClientOption {
+	return func(client *Client) {
+		client.userAgent = ua
+	}
+}
+
+// setUserAgent sets the User-Agent header configured on the Client.
+// An empty value is kept in header so the http.Client does not add its
+// own.
+func (c *Client) setUserAgent(header http.Header) {
+	header["User-Agent"] = []string{c.userAgent}
+}
@@ -0,0 +1,90 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type requestIDKey struct{}
+
+func TestWithContextHeader(t *testing.T) {
+	is := is.New(t)
+	var headers []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Request-ID"))
+		_, ok := r.Header["X-Request-Id"]
+		is.Equal(ok, headers[len(headers)-1] != "") // header omitted without a value
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithContextHeader("X-Request-ID", requestIDKey{}))
+	err := client.Run(context.WithValue(ctx, requestIDKey{}, "f3b1c2"), NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+	err = client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+	err = client.Run(context.WithValue(ctx, requestIDKey{}, 42), NewRequest("query {}", srv.URL), nil)
+	is.NoErr(err)
+	is.Equal(headers, []string{"f3b1c2", "", ""})
+}
+
+func TestWithUserAgent(t *testing.T) {
+	is := is.New(t)
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		if r.UserAgent() == "" {
+			_, ok := r.Header["User-Agent"]
+			is.True(!ok) // header is not sent
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{
+		NewClient(),
+		NewClient(WithUserAgent("inventory-service/2.3")),
+		NewClient(WithUserAgent("")),
+		NewClient(WithUserAgent(""), UseMultipartForm()),
+	} {
+		err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+		is.NoErr(err)
+	}
+	is.Equal(agents, []string{DefaultUserAgent, "inventory-service/2.3", "", ""})
+}
+
+func TestWithHeaders(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("X-Tenant"), "acme")
+		is.Equal(r.Header.Get("X-Client-Version"), "2.0")
+		is.Equal(r.Header.Values("X-Api-Key"), []string{"request-key"}) // the request wins
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	base := http.Header{}
+	base.Set("X-Tenant", "acme")
+	base.Set("X-Api-Key", "base-key")
+	client := NewClient(WithHeaders(base), WithHeaders(http.Header{"X-Client-Version": {"2.0"}}))
+	base.Set("X-Tenant", "changed") // copied by WithHeaders
+
+	req := NewRequest("query {}", srv.URL)
+	req.Header.Set("X-Api-Key", "request-key")
+	is.NoErr(client.Run(ctx, req, nil))
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(client.header.Get("X-Api-Key"), "base-key") // not changed by requests
+	is.Equal(len(client.header), 3)
+}
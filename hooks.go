@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithBeforeRequest adds a hook that is called with every HTTP request
+// right before it is sent, after the Client has set its headers, for
+// example to sign requests. The hook may change the request, including
+// its headers and body. If it returns an error, the request is not sent
+// and the call returns the error. Hooks run in the order they were
+// added, after any rate limiter set with WithRateLimiter.
+func WithBeforeRequest(hook func(ctx context.Context, r *http.Request) error) ClientOption {
+	return func(client *Client) {
+		client.beforeRequest = append(client.beforeRequest, hook)
+	}
+}
+
+// WithAfterResponse adds a hook that is called with every HTTP response
+// before it is decoded, for example to inspect its headers. If it
+// returns an error, the response is discarded and the call returns the
+// error. A hook that reads the body must replace it with one yielding
+// the same content. Hooks run in the order they were added.
+func WithAfterResponse(hook func(ctx context.Context, res *http.Response) error) ClientOption {
+	return func(client *Client) {
+		client.afterResponse = append(client.afterResponse, hook)
+	}
+}
+
+// roundTrip sends r with httpClient once beforeSend allows it. Every
+// HTTP request of the Client is sent with roundTrip, and the handshake
+// of subscriptions, which is not sent with an http.Client, passes
+// through beforeSend as well. Errors of httpClient are returned as a
+// *NetworkError.
+func (c *Client) roundTrip(ctx context.Context, httpClient *http.Client, r *http.Request) (*http.Response, error) {
+	if err := c.beforeSend(ctx, r); err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(r)
+	if err != nil {
+		return nil, &NetworkError{Err: withoutQuery(err)}
+	}
+	return res, nil
+}
+
+// beforeSend waits for the rate limiter and runs the WithBeforeRequest
+// hooks.
+func (c *Client) beforeSend(ctx context.Context, r *http.Request) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	for _, hook := range c.beforeRequest {
+		if err := hook(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterReceive runs the WithAfterResponse hooks.
+func (c *Client) afterReceive(ctx context.Context, res *http.Response) error {
+	for _, hook := range c.afterResponse {
+		if err := hook(ctx, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
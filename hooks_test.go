@@ -0,0 +1,78 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRequestHooks(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("X-Signature"), "signed:"+r.Header.Get("Content-Type"))
+		w.Header().Set("X-Request-Cost", "42")
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var order []string
+	var cost string
+	client := NewClient(
+		WithBeforeRequest(func(ctx context.Context, r *http.Request) error {
+			order = append(order, "before")
+			r.Header.Set("X-Signature", "signed:"+r.Header.Get("Content-Type"))
+			return nil
+		}),
+		WithAfterResponse(func(ctx context.Context, res *http.Response) error {
+			order = append(order, "after")
+			cost = res.Header.Get("X-Request-Cost")
+			return nil
+		}),
+	)
+	var resp struct{ Value string }
+	is.NoErr(client.Run(ctx, NewRequest("query { value }", srv.URL), &resp))
+	is.Equal(resp.Value, "some data")
+	is.Equal(cost, "42")
+	is.Equal(order, []string{"before", "after"})
+	is.Equal(calls, 1)
+}
+
+func TestRequestHookErrors(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	errSigning := errors.New("signing failed")
+	client := NewClient(WithBeforeRequest(func(ctx context.Context, r *http.Request) error {
+		return errSigning
+	}))
+	err := client.Run(ctx, NewRequest("query { value }", srv.URL), nil)
+	is.Equal(err, errSigning)
+	is.Equal(calls, 0) // the request is not sent
+
+	errQuota := errors.New("quota exceeded")
+	client = NewClient(WithAfterResponse(func(ctx context.Context, res *http.Response) error {
+		return errQuota
+	}))
+	var resp struct{ Value string }
+	err = client.Run(ctx, NewRequest("query { value }", srv.URL), &resp)
+	is.Equal(err, errQuota)
+	is.Equal(calls, 1)
+	is.Equal(resp.Value, "") // the response is discarded
+}
@@ -0,0 +1,76 @@
+package graphql
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+)
+
+// WithHTTP2 configures the transport of the Client with
+// golang.org/x/net/http2, so that HTTP/2 is used for https endpoints
+// whose servers support it. If h2c is true, requests to http endpoints
+// are sent over unencrypted HTTP/2 with prior knowledge (h2c), without
+// falling back to HTTP/1.1, which suits calls between trusted services
+// that do not use TLS. The server must accept h2c, for example through
+// golang.org/x/net/http2/h2c.
+// WithHTTP2 has no effect if an http.Client is set with WithHTTPClient;
+// configure its transport instead.
+func WithHTTP2(h2c bool) ClientOption {
+	return func(client *Client) {
+		client.http2 = true
+		client.h2c = h2c
+	}
+}
+
+// withHTTP2 returns a copy of httpClient with a transport configured as
+// set with WithHTTP2, or httpClient itself if its transport is not an
+// *http.Transport. A failure to configure the transport is returned by
+// every call to Run.
+func (c *Client) withHTTP2(httpClient *http.Client) *http.Client {
+	var configured *http2.Transport
+	tuned := tuneTransport(httpClient, func(t *http.Transport) {
+		h2, err := http2.ConfigureTransports(t)
+		if err != nil {
+			c.err = errors.Wrap(err, "graphql: configure HTTP/2")
+			return
+		}
+		configured = h2
+	})
+	if configured == nil || !c.h2c {
+		return tuned
+	}
+	dial := (&net.Dialer{}).DialContext
+	h2c := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+	tuned.Transport = h2cTransport{h2c: h2c, next: tuned.Transport}
+	return tuned
+}
+
+// h2cTransport sends requests to http URLs with h2c and all others
+// with next.
+type h2cTransport struct {
+	h2c  *http2.Transport
+	next http.RoundTripper
+}
+
+func (t h2cTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(r)
+	}
+	return t.next.RoundTrip(r)
+}
+
+func (t h2cTransport) CloseIdleConnections() {
+	t.h2c.CloseIdleConnections()
+	if next, ok := t.next.(idleCloser); ok {
+		next.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestWithHTTP2H2C(t *testing.T) {
+	is := is.New(t)
+	var proto string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	})
+	srv := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithHTTP2(true), WithConnectionPool(10, 10, 0))
+	var resp struct {
+		Value string
+	}
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), &resp))
+	is.Equal(resp.Value, "some data")
+	is.Equal(proto, "HTTP/2.0")
+
+	is.NoErr(NewClient(WithHTTP2(false)).Run(ctx, NewRequest("query {}", srv.URL), &resp))
+	is.Equal(proto, "HTTP/1.1") // h2c is not used without prior knowledge
+}
+
+func TestWithHTTP2TLS(t *testing.T) {
+	is := is.New(t)
+	var proto string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithHTTP2(true))
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	client.httpClient.Transport.(h2cTransport).next.(*http.Transport).TLSClientConfig.RootCAs = roots
+	is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), nil))
+	is.Equal(proto, "HTTP/2.0")
+}
@@ -0,0 +1,230 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// incrementalAccept is the media type requested by RunIncremental in
+// addition to the usual ones.
+const incrementalAccept = "multipart/mixed; deferSpec=20220824"
+
+// IncrementalResult is a single payload of a response delivered
+// incrementally with the @defer and @stream directives.
+type IncrementalResult struct {
+	// Path is the path of the deferred fragment or streamed list in the
+	// result, or nil for the initial payload.
+	Path []interface{}
+	// Label is the label given to the @defer or @stream directive.
+	Label string
+	// Data is the data of the initial payload or of a deferred
+	// fragment.
+	Data json.RawMessage
+	// Items holds the items of a streamed list.
+	Items json.RawMessage
+	// Errors holds the GraphQL errors of the payload.
+	Errors []ErrorEntry
+	// HasNext reports whether the server announced more payloads.
+	HasNext bool
+	// Err is set on the last result delivered when the response could
+	// not be read or decoded.
+	Err error
+}
+
+// Decode unmarshals the data of the result into v, or its items for a
+// streamed list. If the result carries GraphQL errors, a *GraphQLError
+// is returned after v has been filled.
+func (r IncrementalResult) Decode(v interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	raw := r.Data
+	if len(r.Items) > 0 {
+		raw = r.Items
+	}
+	if len(raw) > 0 && v != nil {
+		if err := json.Unmarshal(raw, v); err != nil {
+			return errors.Wrap(err, "decoding result")
+		}
+	}
+	if len(r.Errors) > 0 {
+		return &GraphQLError{Errors: r.Errors}
+	}
+	return nil
+}
+
+// incrementalPayload is a part of a multipart/mixed response. Newer
+// servers wrap the deferred and streamed results in the incremental
+// field, older ones send them as top-level fields of the part.
+type incrementalPayload struct {
+	Data        json.RawMessage
+	Items       json.RawMessage
+	Path        []interface{}
+	Label       string
+	Errors      []ErrorEntry
+	HasNext     *bool
+	Incremental []incrementalPayload
+}
+
+// RunIncremental executes a query that uses the @defer or @stream
+// directives and delivers the initial payload and then every
+// incremental payload on the returned channel as the server sends them
+// in a multipart/mixed response. A server that answers with a single
+// JSON response results in a single IncrementalResult.
+// The channel is closed once the server sends the final boundary or
+// announces that no more payloads follow, or when ctx is cancelled.
+// An error reading the response is reported in the Err field of the
+// last result. RunIncremental is never retried.
+func (c *Client) RunIncremental(ctx context.Context, req *Request) (<-chan IncrementalResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	r, err := c.BuildRequest(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r.Header.Set("Accept", incrementalAccept+", "+c.accept())
+	res, err := c.roundTrip(ctx, c.httpClientFor(req), r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.captureHeader(res)
+	if err := c.afterReceive(ctx, res); err != nil {
+		res.Body.Close()
+		cancel()
+		return nil, err
+	}
+	if !isSuccess(res.StatusCode) {
+		defer cancel()
+		defer res.Body.Close()
+		return nil, c.readHTTPError(res)
+	}
+	results := make(chan IncrementalResult)
+	go func() {
+		defer cancel()
+		defer close(results)
+		defer res.Body.Close()
+		c.readIncremental(ctx, res, results)
+	}()
+	return results, nil
+}
+
+// readIncremental reads the payloads of res and sends them to results
+// until the response ends or ctx is cancelled.
+func (c *Client) readIncremental(ctx context.Context, res *http.Response, results chan<- IncrementalResult) {
+	fail := func(err error) {
+		if ctx.Err() == nil {
+			sendIncremental(ctx, results, IncrementalResult{Err: err})
+		}
+	}
+	body, err := uncompressedBody(res)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer body.Close()
+	mediaType, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		b, err := ioutil.ReadAll(limitResponse(body, c.maxResponseBytes))
+		if err != nil {
+			fail(readError(err))
+			return
+		}
+		if _, err := c.sendPayload(ctx, results, b); err != nil {
+			fail(err)
+		}
+		return
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fail(&NetworkError{Err: errors.Wrap(err, "reading part")})
+			return
+		}
+		b, err := ioutil.ReadAll(limitResponse(part, c.maxResponseBytes))
+		if err != nil {
+			fail(readError(err))
+			return
+		}
+		if len(bytes.TrimSpace(b)) == 0 {
+			// keep-alive part
+			continue
+		}
+		more, err := c.sendPayload(ctx, results, b)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// readError returns the error for a failed read of a response body.
+func readError(err error) error {
+	if err == ErrResponseTooLarge {
+		return err
+	}
+	return &NetworkError{Err: errors.Wrap(err, "reading body")}
+}
+
+// sendPayload decodes a payload and sends its results, and reports
+// whether more payloads follow.
+func (c *Client) sendPayload(ctx context.Context, results chan<- IncrementalResult, b []byte) (bool, error) {
+	c.logf("<< %s", b)
+	var p incrementalPayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return false, &DecodeError{Err: err}
+	}
+	hasNext := p.HasNext != nil && *p.HasNext
+	if len(p.Data) > 0 || len(p.Items) > 0 || len(p.Errors) > 0 {
+		if !sendIncremental(ctx, results, IncrementalResult{
+			Path:    p.Path,
+			Label:   p.Label,
+			Data:    p.Data,
+			Items:   p.Items,
+			Errors:  p.Errors,
+			HasNext: hasNext,
+		}) {
+			return false, nil
+		}
+	}
+	for _, inc := range p.Incremental {
+		if !sendIncremental(ctx, results, IncrementalResult{
+			Path:    inc.Path,
+			Label:   inc.Label,
+			Data:    inc.Data,
+			Items:   inc.Items,
+			Errors:  inc.Errors,
+			HasNext: hasNext,
+		}) {
+			return false, nil
+		}
+	}
+	return p.HasNext == nil || hasNext, nil
+}
+
+// sendIncremental sends result unless ctx is cancelled first, and
+// reports whether it was sent.
+func sendIncremental(ctx context.Context, results chan<- IncrementalResult, result IncrementalResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
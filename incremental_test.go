@@ -0,0 +1,134 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunIncremental(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.True(strings.HasPrefix(r.Header.Get("Accept"), "multipart/mixed"))
+		w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+		for _, part := range []string{
+			`{"data":{"user":{"id":"1"}},"hasNext":true}`,
+			`{"incremental":[{"data":{"name":"Mat"},"path":["user"],"label":"profile"}],"hasNext":true}`,
+			`{"items":[{"id":"2"}],"path":["user","friends",0],"errors":[{"message":"partial"}],"hasNext":false}`,
+		} {
+			io.WriteString(w, "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"+part)
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, "\r\n-----\r\n")
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	results, err := client.RunIncremental(ctx, NewRequest("query { user { id ... @defer(label: \"profile\") { name } } }", srv.URL))
+	is.NoErr(err)
+	var got []IncrementalResult
+	for result := range results {
+		got = append(got, result)
+	}
+	is.Equal(len(got), 3)
+
+	var initial struct{ User struct{ ID string } }
+	is.NoErr(got[0].Decode(&initial))
+	is.Equal(initial.User.ID, "1")
+	is.Equal(got[0].Path, nil)
+	is.True(got[0].HasNext)
+
+	var profile struct{ Name string }
+	is.NoErr(got[1].Decode(&profile))
+	is.Equal(profile.Name, "Mat")
+	is.Equal(got[1].Path, []interface{}{"user"})
+	is.Equal(got[1].Label, "profile")
+
+	var items []struct{ ID string }
+	err = got[2].Decode(&items)
+	is.True(IsGraphQLError(err))
+	is.Equal(items[0].ID, "2")
+	is.Equal(got[2].Path, []interface{}{"user", "friends", float64(0)})
+	is.True(!got[2].HasNext)
+}
+
+func TestRunIncrementalSingleResponse(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"something":"yes"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	results, err := client.RunIncremental(ctx, NewRequest("query {}", srv.URL))
+	is.NoErr(err)
+	result, ok := <-results
+	is.True(ok)
+	var resp struct{ Something string }
+	is.NoErr(result.Decode(&resp))
+	is.Equal(resp.Something, "yes")
+	_, ok = <-results
+	is.True(!ok) // closed after the single response
+}
+
+func TestRunIncrementalCancel(t *testing.T) {
+	is := is.New(t)
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				io.WriteString(pw, "\r\n---\r\nContent-Type: application/json\r\n\r\n"+`{"data":{},"hasNext":true}`+"\r\n---\r\n")
+				<-req.Context().Done()
+				pw.CloseWithError(req.Context().Err())
+			}()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{`multipart/mixed; boundary="-"`}},
+				Body:       pr,
+			}, nil
+		}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := NewClient(WithHTTPClient(testClient))
+	results, err := client.RunIncremental(ctx, NewRequest("query {}", "http://example.com/graphql"))
+	is.NoErr(err)
+	result := <-results
+	is.NoErr(result.Err)
+	is.True(result.HasNext)
+	cancel()
+	select {
+	case result, ok := <-results:
+		is.True(!ok) // closed without an error
+		is.NoErr(result.Err)
+	case <-time.After(1 * time.Second):
+		t.Fatal("results not closed after cancel")
+	}
+}
+
+func TestRunIncrementalHTTPError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	results, err := client.RunIncremental(ctx, NewRequest("query {}", srv.URL))
+	is.True(IsHTTPError(err))
+	is.Equal(results, nil)
+}
@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRequestTooLarge is returned by Run when the body of a request is
+// larger than the limit set with WithMaxRequestBytes.
+var ErrRequestTooLarge = errors.New("graphql: request body exceeds the limit set with WithMaxRequestBytes")
+
+// WithMaxRequestBytes makes Run fail with ErrRequestTooLarge, without
+// sending anything, when the body of a request is larger than n bytes,
+// as a guard against accidentally sending huge variables. The limit
+// applies to the body as sent, after compression. Multipart bodies are
+// checked while the files are written, so a large file is not read to
+// the end.
+func WithMaxRequestBytes(n int64) ClientOption {
+	return func(client *Client) {
+		client.maxRequestBytes = n
+	}
+}
+
+// WithErrorBodyLimit sets how many bytes of the body of a response are
+// kept in the Body of an *HTTPError or *ContentTypeError, 2KB by
+// default. Error pages can be large and contain data that should not
+// end up in logs; zero or less keeps none of the body.
+func WithErrorBodyLimit(n int) ClientOption {
+	return func(client *Client) {
+		if n < 0 {
+			n = 0
+		}
+		client.errorBodyLimit = n
+	}
+}
+
+// checkRequestSize returns ErrRequestTooLarge if a request body of n
+// bytes exceeds the limit set with WithMaxRequestBytes.
+func (c *Client) checkRequestSize(n int) error {
+	if c.maxRequestBytes > 0 && int64(n) > c.maxRequestBytes {
+		return ErrRequestTooLarge
+	}
+	return nil
+}
+
+// limitRequestWriter returns w, or a writer that fails with
+// ErrRequestTooLarge once more than the limit set with
+// WithMaxRequestBytes is written to w.
+func (c *Client) limitRequestWriter(w io.Writer) io.Writer {
+	if c.maxRequestBytes <= 0 {
+		return w
+	}
+	return &limitedWriter{w: w, remaining: c.maxRequestBytes}
+}
+
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		return 0, ErrRequestTooLarge
+	}
+	l.remaining -= int64(len(p))
+	return l.w.Write(p)
+}
+
+// ErrResponseTooLarge is returned by Run when the body of a response is
+// larger than the limit set with WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("graphql: response body exceeds the limit set with WithMaxResponseBytes")
+
+// WithMaxResponseBytes makes Run fail with ErrResponseTooLarge when the
+// body of a response is larger than n bytes, which protects against
+// running out of memory because of a buggy or hostile server. The limit
+// applies to the decompressed body, and to both buffered and streaming
+// decoding; reading stops as soon as it is exceeded.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(client *Client) {
+		client.maxResponseBytes = n
+	}
+}
+
+// limitResponse returns r, or a reader that fails with
+// ErrResponseTooLarge once more than maxBytes are read from r if
+// maxBytes is positive.
+func limitResponse(r io.Reader, maxBytes int64) io.Reader {
+	if maxBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, remaining: maxBytes}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n - 1, ErrResponseTooLarge
+	}
+	return n, err
+}
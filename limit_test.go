@@ -0,0 +1,114 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestWithMaxRequestBytes(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithMaxRequestBytes(1024))
+	req := NewRequest("mutation ($input: String!) { save(input: $input) }", srv.URL)
+	req.Var("input", "small")
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(calls, 1)
+
+	req.Var("input", strings.Repeat("large", 1024))
+	err := client.Run(ctx, req, nil)
+	is.True(errors.Is(err, ErrRequestTooLarge))
+	is.Equal(calls, 1) // the request is not sent
+}
+
+func TestWithMaxRequestBytesMultipart(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(UseMultipartForm(), WithMaxRequestBytes(64*1024))
+	req := NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	req.FileVar("variables.file", "small.txt", strings.NewReader("some file"))
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(calls, 1)
+
+	large := &countingReader{r: strings.NewReader(strings.Repeat("x", 10*1024*1024))}
+	req = NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	req.FileVar("variables.file", "large.txt", large)
+	err := client.Run(ctx, req, nil)
+	is.True(errors.Is(err, ErrRequestTooLarge))
+	is.Equal(calls, 1)          // the request is not sent
+	is.True(large.n < 128*1024) // the file is not read to the end
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			io.WriteString(w, `{"data":{"value":"`+strings.Repeat("x", 1024*1024)+`"}}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"value":"small"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{
+		NewClient(WithMaxResponseBytes(1024)),
+		NewClient(WithMaxResponseBytes(1024), WithStreamingResponses()),
+	} {
+		var resp struct {
+			Value string
+		}
+		is.NoErr(client.Run(ctx, NewRequest("query { value }", srv.URL), &resp))
+		is.Equal(resp.Value, "small")
+
+		err := client.Run(ctx, NewRequest("query { value }", srv.URL+"/large"), &resp)
+		is.Equal(err, ErrResponseTooLarge)
+	}
+}
+
+func TestLimitResponse(t *testing.T) {
+	is := is.New(t)
+	b, err := ioutil.ReadAll(limitResponse(strings.NewReader("12345"), 5))
+	is.NoErr(err)
+	is.Equal(string(b), "12345")
+	b, err = ioutil.ReadAll(limitResponse(strings.NewReader("123456"), 5))
+	is.Equal(err, ErrResponseTooLarge)
+	is.Equal(string(b), "12345")
+}
@@ -0,0 +1,74 @@
+package graphql
+
+import "net/http"
+
+// Logger receives structured logs from the Client. keysAndValues holds
+// alternating keys and values, which makes it easy to adapt loggers
+// such as zap's SugaredLogger or slog.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// WithLogger sets a Logger that receives debug logs for every request,
+// including its query, variables and the response status, info logs for
+// retries, and error logs for failed requests and responses that cannot
+// be decoded. Nothing is logged by default.
+func WithLogger(l Logger) ClientOption {
+	return func(client *Client) {
+		client.logger = l
+	}
+}
+
+// WithVariableRedactor sets a function that is called for every variable
+// before it is logged, and returns the value to log in its place. Use it
+// to keep secrets and personal data out of logs; the variables sent to
+// the server are not affected. Variables marked with Request.RedactVar
+// are always logged as "***".
+func WithVariableRedactor(fn func(key string, value interface{}) interface{}) ClientOption {
+	return func(client *Client) {
+		client.redactVar = fn
+	}
+}
+
+// redactedValue replaces the variables marked with Request.RedactVar in
+// logs.
+const redactedValue = "***"
+
+// redactVars returns the variables of req as they are logged, with the
+// variables marked with Request.RedactVar and the variable redactor
+// applied.
+func (c *Client) redactVars(req *Request) map[string]interface{} {
+	if (c.redactVar == nil && len(req.redacted) == 0) || req.vars == nil {
+		return req.vars
+	}
+	redacted := make(map[string]interface{}, len(req.vars))
+	for key, value := range req.vars {
+		switch {
+		case req.redacted[key]:
+			redacted[key] = redactedValue
+		case c.redactVar != nil:
+			redacted[key] = c.redactVar(key, value)
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+// redactedHeaders are the headers that carry credentials, which are
+// logged as "***".
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// redactHeader returns a copy of header as it is logged, with the
+// values of the headers that carry credentials redacted.
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = []string{redactedValue}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,158 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) log(level, msg string, keysAndValues ...interface{}) {
+	l.lines = append(l.lines, strings.TrimSpace(fmt.Sprintln(append([]interface{}{level, msg}, keysAndValues...)...)))
+}
+
+func (l *testLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log("DEBUG", msg, keysAndValues...)
+}
+
+func (l *testLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log("INFO", msg, keysAndValues...)
+}
+
+func (l *testLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log("ERROR", msg, keysAndValues...)
+}
+
+func TestWithLogger(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	logger := &testLogger{}
+	client := NewClient(WithLogger(logger), WithVariableRedactor(func(key string, value interface{}) interface{} {
+		if key == "password" {
+			return "***"
+		}
+		return value
+	}))
+	req := NewRequest("query {}", srv.URL)
+	req.Var("username", "matryer")
+	req.Var("password", "secret")
+	err := client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.Equal(logger.lines, []string{
+		"DEBUG graphql request endpoint " + srv.URL + " query query {} variables map[password:*** username:matryer]",
+		"DEBUG graphql response endpoint " + srv.URL + " status 200",
+		"ERROR graphql decode failed endpoint " + srv.URL + " status 200 error decoding response: unexpected EOF",
+	})
+	is.Equal(req.Vars()["password"], "secret")
+}
+
+func TestWithLoggerOperationName(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	logger := &testLogger{}
+	client := NewClient(WithLogger(logger))
+	err := client.Run(ctx, NewRequest("query GetUser { user }", srv.URL), nil)
+	is.NoErr(err)
+	is.Equal(logger.lines[0], "DEBUG graphql request endpoint "+srv.URL+" operation GetUser query query GetUser { user } variables map[]")
+}
+
+func TestRedactVar(t *testing.T) {
+	is := is.New(t)
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		received = append(received, string(b))
+		io.WriteString(w, `{"data":`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, opts := range [][]ClientOption{nil, {UseMultipartForm()}, {UseGETForQueries()}} {
+		logger := &testLogger{}
+		client := NewClient(append(opts, WithLogger(logger))...)
+		var legacy []string
+		client.Log = func(s string) { legacy = append(legacy, s) }
+		req := NewRequest("query ($username: String!, $password: String!) { login }", srv.URL)
+		req.Var("username", "matryer")
+		req.Var("password", "s3cret")
+		req.RedactVar("password")
+		is.True(client.Run(ctx, req, nil) != nil)
+		output := strings.Join(append(logger.lines, legacy...), "\n")
+		is.True(strings.Contains(output, "password:***")) // redacted
+		is.True(strings.Contains(output, "matryer"))      // not redacted
+		is.True(!strings.Contains(output, "s3cret"))      // never logged
+	}
+	is.Equal(len(received), 3)
+	is.True(strings.Contains(received[0], "s3cret")) // sent as JSON
+	is.True(strings.Contains(received[1], "s3cret")) // sent as multipart
+}
+
+func TestRedactVarBatch(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"data":{}},{"data":{}}]`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	var logs []string
+	client.Log = func(s string) { logs = append(logs, s) }
+	reqA := NewRequest("query ($password: String!) { login }", srv.URL)
+	reqA.Var("password", "s3cret")
+	reqA.RedactVar("password")
+	reqB := NewRequest("query { me }", srv.URL)
+	is.NoErr(client.RunBatch(ctx, []*Request{reqA, reqB}, []interface{}{nil, nil}))
+	output := strings.Join(logs, "\n")
+	is.True(strings.Contains(output, ">> batch 0 variables: map[password:***]")) // redacted
+	is.True(strings.Contains(output, ">> batch 1 query: query { me }"))
+	is.True(!strings.Contains(output, "s3cret")) // never logged
+}
+
+func TestRedactVarGETNetworkError(t *testing.T) {
+	is := is.New(t)
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	logger := &testLogger{}
+	client := NewClient(WithHTTPClient(testClient), UseGETForQueries(), WithLogger(logger))
+	req := NewRequest("query ($pw: String!) { login }", "http://example.com/graphql")
+	req.Var("pw", "hunter2")
+	req.RedactVar("pw")
+	err := client.Run(ctx, req, nil)
+	is.True(IsNetworkError(err))
+	is.Equal(err.Error(), `Get "http://example.com/graphql": connection refused`) // no query string
+	is.True(!strings.Contains(strings.Join(logger.lines, "\n"), "hunter2"))       // never logged
+}
@@ -0,0 +1,78 @@
+package graphql
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// graphQLResponseJSON is the media type of GraphQL responses defined by
+// the GraphQL over HTTP specification.
+const graphQLResponseJSON = "application/graphql-response+json"
+
+// UseGraphQLResponseJSON makes the Client ask for responses of the
+// application/graphql-response+json media type of the GraphQL over HTTP
+// specification, falling back to application/json for servers that do
+// not support it.
+//
+// Responses of that type are interpreted according to the
+// specification: a response with a non-2xx status code may still carry
+// GraphQL errors, such as validation errors with a 400 status code, in
+// which case Run returns a *GraphQLError instead of an *HTTPError. This
+// applies whenever a server responds with the media type, with or
+// without the option.
+func UseGraphQLResponseJSON() ClientOption {
+	return func(client *Client) {
+		client.graphQLResponseJSON = true
+	}
+}
+
+// WithAccept sets the Accept header of requests to value instead of
+// application/json, for servers that choose the format of their
+// responses by it. It takes precedence over UseGraphQLResponseJSON.
+// Responses are interpreted by their Content-Type, so a server that
+// answers with application/graphql-response+json, for example because
+// value lists it, has its errors reported as described for
+// UseGraphQLResponseJSON.
+func WithAccept(value string) ClientOption {
+	return func(client *Client) {
+		client.acceptHeader = value
+	}
+}
+
+// accept returns the Accept header of requests.
+func (c *Client) accept() string {
+	if c.acceptHeader != "" {
+		return c.acceptHeader
+	}
+	if c.graphQLResponseJSON {
+		return graphQLResponseJSON + ", application/json;q=0.9"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// isJSONMediaType reports whether contentType is a JSON media type,
+// such as application/json or application/graphql-response+json.
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json"))
+}
+
+// isGraphQLResponseJSON reports whether res has the
+// application/graphql-response+json media type.
+func isGraphQLResponseJSON(res *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return err == nil && mediaType == graphQLResponseJSON
+}
+
+// statusError returns the error for res, which has a non-2xx status
+// code, and its body: the GraphQL errors in the body if res has the
+// application/graphql-response+json media type, or an *HTTPError.
+func (c *Client) statusError(res *http.Response, body []byte, req *Request, resp interface{}) error {
+	if isGraphQLResponseJSON(res) {
+		if err := c.decodeBody(body, req, resp); IsGraphQLError(err) {
+			return err
+		}
+	}
+	return c.newHTTPError(res, body)
+}
@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestUseGraphQLResponseJSON(t *testing.T) {
+	is := is.New(t)
+	var accepts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "application/graphql-response+json; charset=utf-8")
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{NewClient(), NewClient(UseGraphQLResponseJSON())} {
+		var resp struct {
+			Value string
+		}
+		is.NoErr(client.Run(ctx, NewRequest("query { value }", srv.URL), &resp))
+		is.Equal(resp.Value, "some data")
+	}
+	is.Equal(accepts, []string{
+		"application/json; charset=utf-8",
+		"application/graphql-response+json, application/json;q=0.9",
+	})
+}
+
+func TestGraphQLResponseJSONErrorStatus(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graphql-response":
+			w.Header().Set("Content-Type", "application/graphql-response+json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"errors":[{"message":"Cannot query field \"nope\" on type \"Query\"."}]}`)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"errors":[{"message":"Cannot query field \"nope\" on type \"Query\"."}]}`)
+		case "/html":
+			w.Header().Set("Content-Type", "application/graphql-response+json")
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, `<html>bad gateway</html>`)
+		}
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{
+		NewClient(UseGraphQLResponseJSON()),
+		NewClient(UseGraphQLResponseJSON(), WithStreamingResponses()),
+	} {
+		err := client.Run(ctx, NewRequest("query { nope }", srv.URL+"/graphql-response"), nil)
+		var gqlErr *GraphQLError
+		is.True(errors.As(err, &gqlErr))
+		is.Equal(gqlErr.Errors[0].Message, `Cannot query field "nope" on type "Query".`)
+
+		err = client.Run(ctx, NewRequest("query { nope }", srv.URL+"/json"), nil)
+		var httpErr *HTTPError
+		is.True(errors.As(err, &httpErr))
+		is.Equal(httpErr.StatusCode, http.StatusBadRequest)
+
+		err = client.Run(ctx, NewRequest("query { nope }", srv.URL+"/html"), nil)
+		is.True(errors.As(err, &httpErr))
+		is.Equal(httpErr.StatusCode, http.StatusBadGateway)
+		is.Equal(httpErr.Body, `<html>bad gateway</html>`)
+	}
+}
+
+func TestWithAccept(t *testing.T) {
+	is := is.New(t)
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		// a server negotiating the format of its responses
+		if strings.Contains(accept, "application/graphql-response+json") {
+			w.Header().Set("Content-Type", "application/graphql-response+json")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"errors":[{"message":"invalid query"}]}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	err := NewClient(WithAccept("application/json")).Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.Equal(accept, "application/json")
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr)) // a plain HTTP error
+
+	client := NewClient(UseGraphQLResponseJSON(), WithAccept("application/graphql-response+json"))
+	err = client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.Equal(accept, "application/graphql-response+json") // takes precedence
+	is.Equal(err.Error(), "graphql: invalid query")       // the GraphQL errors of the response
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// RequestMetrics describes a single call to Run.
+type RequestMetrics struct {
+	// OperationName is the name set with Request.OpName, or else with
+	// Request.OperationName, or else the name of the first named
+	// operation in the query. It is empty for anonymous operations.
+	OperationName string
+	// Endpoint is the endpoint of the request.
+	Endpoint string
+	// Duration is the time spent in Run, including any retries.
+	Duration time.Duration
+	// StatusCode is the HTTP status code of the last response, or zero
+	// if no response was received.
+	StatusCode int
+	// BytesRead is the number of bytes read from response bodies.
+	BytesRead int64
+	// GraphQLErrors reports whether the response contained GraphQL
+	// errors.
+	GraphQLErrors bool
+	// Err is the error returned by Run.
+	Err error
+}
+
+// WithMetrics sets a function that is called exactly once for every
+// call to Run, whether it succeeds or not, which can be used to record
+// metrics such as request durations.
+// Name operations in the query or with Request.OpName to label them.
+func WithMetrics(fn func(m RequestMetrics)) ClientOption {
+	return func(client *Client) {
+		client.metrics = fn
+	}
+}
+
+// statsKey is the context key for the *callStats of a call to Run.
+type statsKey struct{}
+
+// callStats collects the statistics of a call to Run.
+type callStats struct {
+	statusCode int
+	bytesRead  int64
+}
+
+// runInstrumented calls run, reporting metrics, tracing spans and
+// timings as configured.
+func (c *Client) runInstrumented(ctx context.Context, req *Request, resp interface{}) error {
+	stats := &callStats{}
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, req)
+	var timings *timingCollector
+	if c.clientTrace != nil {
+		timings = &timingCollector{}
+		ctx = timings.withTrace(ctx)
+	}
+	err := c.run(context.WithValue(ctx, statsKey{}, stats), req, resp)
+	_, gqlErr := err.(*GraphQLError)
+	if span != nil {
+		span.SetAttribute("http.status_code", stats.statusCode)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}
+	if c.metrics != nil {
+		c.metrics(RequestMetrics{
+			OperationName: req.label(),
+			Endpoint:      c.endpointOf(req),
+			Duration:      time.Since(start),
+			StatusCode:    stats.statusCode,
+			BytesRead:     stats.bytesRead,
+			GraphQLErrors: gqlErr,
+			Err:           err,
+		})
+	}
+	if timings != nil {
+		if t, ok := timings.result(); ok {
+			t.OperationName = req.label()
+			t.Endpoint = c.endpointOf(req)
+			c.clientTrace(t)
+		}
+	}
+	return err
+}
+
+// countingReadCloser adds the number of bytes read to n.
+type countingReadCloser struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.n += int64(n)
+	return n, err
+}
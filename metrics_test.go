@@ -0,0 +1,86 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithMetrics(t *testing.T) {
+	is := is.New(t)
+	const body = `{"data":{"value":null},"errors":[{"message":"not found"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var metrics []RequestMetrics
+	client := NewClient(WithMetrics(func(m RequestMetrics) {
+		metrics = append(metrics, m)
+	}))
+	req := NewRequest("query {}", srv.URL)
+	req.OpName("GetValue")
+	err := client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.Equal(len(metrics), 1)
+	m := metrics[0]
+	is.Equal(m.OperationName, "GetValue")
+	is.Equal(m.Endpoint, srv.URL)
+	is.True(m.Duration > 0)
+	is.Equal(m.StatusCode, http.StatusOK)
+	is.Equal(m.BytesRead, int64(len(body)))
+	is.True(m.GraphQLErrors)
+	is.Equal(m.Err, err)
+}
+
+func TestWithMetricsFailure(t *testing.T) {
+	is := is.New(t)
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}),
+	}
+	var metrics []RequestMetrics
+	client := NewClient(WithHTTPClient(testClient), WithMetrics(func(m RequestMetrics) {
+		metrics = append(metrics, m)
+	}))
+	err := client.Run(context.Background(), NewRequest("query {}", "http://example.com/graphql"), nil)
+	is.True(err != nil)
+	is.Equal(len(metrics), 1)
+	is.Equal(metrics[0].StatusCode, 0)
+	is.True(!metrics[0].GraphQLErrors)
+	is.Equal(metrics[0].Err, err)
+}
+
+func TestMetricsOperationNameFromQuery(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var names []string
+	client := NewClient(WithMetrics(func(m RequestMetrics) {
+		names = append(names, m.OperationName)
+	}))
+	overridden := NewRequest("query GetUser($id: ID!) { user(id: $id) { name } }", srv.URL)
+	overridden.OpName("UserProfile")
+	for _, req := range []*Request{
+		NewRequest("query GetUser($id: ID!) { user(id: $id) { name } }", srv.URL),
+		NewRequest("{ user { name } }", srv.URL),
+		overridden,
+	} {
+		is.NoErr(client.Run(ctx, req, nil))
+	}
+	is.Equal(names, []string{"GetUser", "", "UserProfile"})
+}
@@ -0,0 +1,311 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// hasFileVars reports whether any file of req is bound to a variable
+// path, in which case the request is sent following the GraphQL
+// multipart request specification.
+func (req *Request) hasFileVars() bool {
+	for i := range req.files {
+		if req.files[i].Path != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFileFields returns an error if two files that are not bound to
+// variables are sent in the same form field, which servers read as a
+// single file or reject.
+func checkFileFields(files []File) error {
+	fields := make(map[string]bool, len(files))
+	for i := range files {
+		if files[i].Path != "" {
+			continue
+		}
+		if fields[files[i].Field] {
+			return errors.Errorf("graphql: duplicate file field %q", files[i].Field)
+		}
+		fields[files[i].Field] = true
+	}
+	return nil
+}
+
+// WithMultipartBoundary sets the boundary that separates the parts of
+// multipart requests instead of a random one, which makes the request
+// bodies reproducible, for example for snapshot tests. The boundary
+// must consist of 1 to 70 characters allowed by RFC 2046; otherwise
+// every call to Run returns an error.
+func WithMultipartBoundary(boundary string) ClientOption {
+	return func(client *Client) {
+		if err := multipart.NewWriter(ioutil.Discard).SetBoundary(boundary); err != nil {
+			client.err = errors.Wrap(err, "graphql: multipart boundary")
+			return
+		}
+		client.boundary = boundary
+	}
+}
+
+// writeOperations writes the operations and map fields of the GraphQL
+// multipart request specification, in the order it requires: the
+// operations first, then the map, and the files after both. The
+// variables bound to files are sent as null, and the map field links
+// the file parts, named by their index, to those variables.
+func (c *Client) writeOperations(writer *multipart.Writer, req *Request) error {
+	vars := interface{}(req.vars)
+	var files fileMap
+	for i := range req.files {
+		path := req.files[i].Path
+		if path == "" {
+			continue
+		}
+		segments := strings.Split(path, ".")
+		if segments[0] != "variables" {
+			return errors.Errorf("graphql: file path %q must start with variables", path)
+		}
+		vars = withNull(vars, segments[1:])
+		files = append(files, []string{path})
+	}
+	body := newJSONBody(req)
+	body.Variables, _ = vars.(map[string]interface{})
+	operationsField, err := writer.CreateFormField("operations")
+	if err != nil {
+		return errors.Wrap(err, "create operations field")
+	}
+	if err := c.newEncoder(operationsField).Encode(body); err != nil {
+		return errors.Wrap(err, "encode operations")
+	}
+	mapField, err := writer.CreateFormField("map")
+	if err != nil {
+		return errors.Wrap(err, "create map field")
+	}
+	// the map is written as is, as its keys are already in a fixed
+	// order that a canonical encoder would sort as strings
+	b, err := files.MarshalJSON()
+	if err != nil {
+		return errors.Wrap(err, "encode map")
+	}
+	if _, err := mapField.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "write map")
+	}
+	return nil
+}
+
+// fileMap is the map field of a multipart request: the paths of the
+// variables each file is bound to, by index of the file. It is encoded
+// as a JSON object with its keys in the order of the files, rather than
+// sorted as strings, so "10" follows "9".
+type fileMap [][]string
+
+func (m fileMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, paths := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(paths)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, `"%d":%s`, i, b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// withNull returns a copy of v with the value at path set to nil.
+// Maps and slices along the path are copied rather than modified, and
+// missing ones are created. Values of other types are left as they are.
+func withNull(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return nil
+	}
+	index, indexErr := strconv.Atoi(path[0])
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value)+1)
+		for key, val := range value {
+			m[key] = val
+		}
+		m[path[0]] = withNull(value[path[0]], path[1:])
+		return m
+	case []interface{}:
+		if indexErr != nil || index < 0 {
+			return v
+		}
+		size := len(value)
+		if index >= size {
+			size = index + 1
+		}
+		s := make([]interface{}, size)
+		copy(s, value)
+		s[index] = withNull(s[index], path[1:])
+		return s
+	case nil:
+		if indexErr == nil && index >= 0 {
+			return withNull([]interface{}{}, path)
+		}
+		return withNull(map[string]interface{}{}, path)
+	}
+	return v
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// createFilePart creates the part of the file f in the form field
+// fieldname.
+func createFilePart(writer *multipart.Writer, fieldname string, f File, contentType string) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(fieldname), quoteEscaper.Replace(f.Name)))
+	h.Set("Content-Type", contentType)
+	if f.Size > 0 {
+		h.Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	}
+	return writer.CreatePart(h)
+}
+
+// fileContentType returns the content type of f, sniffing it from the
+// start of the file if it is not set. The returned reader yields the
+// whole file, including any bytes read for sniffing.
+func fileContentType(f File) (string, io.Reader, error) {
+	if f.ContentType != "" {
+		return f.ContentType, f.R, nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f.R, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+	return http.DetectContentType(head), io.MultiReader(bytes.NewReader(head), f.R), nil
+}
+
+// writeFiles writes a part for every file and closes writer. Files
+// bound to variables are named by their index, as referenced in the map
+// field written by writeOperations. The progress of writing the files
+// is reported to progress, unless it is nil. Reading the files stops
+// with the error of ctx once it is done.
+func writeFiles(ctx context.Context, writer *multipart.Writer, files []File, progress *uploadProgress) error {
+	var fileVars int
+	for i := range files {
+		fieldname := files[i].Field
+		if files[i].Path != "" {
+			fieldname = strconv.Itoa(fileVars)
+			fileVars++
+		}
+		contentType, r, err := fileContentType(files[i])
+		if err != nil {
+			return errors.Wrap(err, "preparing file")
+		}
+		part, err := createFilePart(writer, fieldname, files[i], contentType)
+		if err != nil {
+			return errors.Wrap(err, "create form file")
+		}
+		if progress != nil {
+			part = progress.writer(part)
+		}
+		if _, err := io.Copy(part, contextReader{ctx: ctx, r: r}); err != nil {
+			return errors.Wrap(err, "preparing file")
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return errors.Wrap(err, "close writer")
+	}
+	return nil
+}
+
+// contextReader reads from r until ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// switchWriter writes to w, which can be changed between writes.
+type switchWriter struct {
+	w io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// pipeBody is a request body written by a function in its own
+// goroutine while the body is read, so that files are streamed to the
+// server instead of being held in memory. The goroutine is started by
+// the first call to Read, and stops when the body is closed or ctx,
+// the context of the request, is done; a read of a file that blocks
+// delays this until the read returns.
+type pipeBody struct {
+	write func(context.Context, io.Writer) error
+	ctx   context.Context
+	r     *io.PipeReader
+	w     *io.PipeWriter
+	once  sync.Once
+	done  chan struct{}
+}
+
+func newPipeBody(write func(context.Context, io.Writer) error) *pipeBody {
+	r, w := io.Pipe()
+	return &pipeBody{write: write, ctx: context.Background(), r: r, w: w, done: make(chan struct{})}
+}
+
+func (b *pipeBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go func() {
+			defer close(b.done)
+			b.w.CloseWithError(b.write(b.ctx, b.w))
+		}()
+		go func() {
+			// The transport waits for the body to be read even when
+			// the request is cancelled, so a file that blocks must
+			// not block the body as well.
+			select {
+			case <-b.ctx.Done():
+				b.w.CloseWithError(b.ctx.Err())
+			case <-b.done:
+			}
+		}()
+	})
+	return b.r.Read(p)
+}
+
+// Close makes the writing goroutine stop at its next write.
+func (b *pipeBody) Close() error {
+	return b.r.Close()
+}
+
+// wait waits for the writing goroutine to return, if it was started,
+// or for ctx to be done.
+func (b *pipeBody) wait(ctx context.Context) {
+	b.once.Do(func() {
+		close(b.done)
+	})
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+	}
+}
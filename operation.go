@@ -0,0 +1,201 @@
+package graphql
+
+import "strings"
+
+// operationType returns the type of the first operation in the query
+// document q: "query", "mutation" or "subscription". Leading whitespace,
+// commas and comments are skipped, and the shorthand { ... } form is a
+// query. An empty string is returned if q does not start with an
+// operation.
+func operationType(q string) string {
+	q = skipIgnored(q)
+	if strings.HasPrefix(q, "{") {
+		return "query"
+	}
+	name := readName(q)
+	switch name {
+	case "query", "mutation", "subscription":
+		return name
+	}
+	return ""
+}
+
+// skipIgnored returns q without its leading whitespace, commas,
+// comments and byte order marks, which are insignificant in GraphQL
+// documents.
+func skipIgnored(q string) string {
+	for len(q) > 0 {
+		switch q[0] {
+		case ' ', '\t', '\n', '\r', ',':
+			q = q[1:]
+		case '#':
+			end := strings.IndexAny(q, "\n\r")
+			if end < 0 {
+				return ""
+			}
+			q = q[end:]
+		default:
+			if strings.HasPrefix(q, "\ufeff") {
+				q = q[len("\ufeff"):]
+				continue
+			}
+			return q
+		}
+	}
+	return q
+}
+
+// readName returns the GraphQL name at the start of q.
+func readName(q string) string {
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9' {
+			continue
+		}
+		return q[:i]
+	}
+	return q
+}
+
+// operationCount returns the number of operations defined in the query
+// document q, ignoring fragments.
+func operationCount(q string) int {
+	return len(operations(q))
+}
+
+// selectedOperationType returns the type of the operation of q that is
+// executed when the given operation name is requested, or an empty
+// string if there is no such operation. Without a name, the document
+// must define a single operation, which may follow fragments.
+func selectedOperationType(q, operationName string) string {
+	ops := operations(q)
+	if operationName == "" {
+		if len(ops) != 1 {
+			return ""
+		}
+		return ops[0].typ
+	}
+	for _, op := range ops {
+		if op.name == operationName {
+			return op.typ
+		}
+	}
+	return ""
+}
+
+// firstOperationName returns the name of the first named operation in
+// the query document q, or an empty string if there is none.
+func firstOperationName(q string) string {
+	for _, op := range operations(q) {
+		if op.name != "" {
+			return op.name
+		}
+	}
+	return ""
+}
+
+// operation is an operation defined in a query document.
+type operation struct {
+	typ  string
+	name string
+}
+
+// operations returns the operations defined in the query document q,
+// ignoring fragments.
+func operations(q string) []operation {
+	var ops []operation
+	var depth int
+	var inDefinition bool
+	for {
+		q = skipIgnored(q)
+		if q == "" {
+			return ops
+		}
+		switch c := q[0]; {
+		case c == '"':
+			q = skipString(q)
+			continue
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && c == '{' && !inDefinition {
+				ops = append(ops, operation{typ: "query"})
+			}
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth == 0 && c == '}' {
+				inDefinition = false
+			}
+		case depth == 0 && !inDefinition:
+			if name := readName(q); name != "" {
+				switch name {
+				case "query", "mutation", "subscription":
+					ops = append(ops, operation{typ: name, name: readName(skipIgnored(q[len(name):]))})
+				}
+				inDefinition = true
+				q = q[len(name):]
+				continue
+			}
+		}
+		q = q[1:]
+	}
+}
+
+// skipString returns q without the string or block string at its
+// start.
+func skipString(q string) string {
+	if strings.HasPrefix(q, `"""`) {
+		q = q[3:]
+		for {
+			end := strings.Index(q, `"""`)
+			if end < 0 {
+				return ""
+			}
+			if end > 0 && q[end-1] == '\\' {
+				q = q[end+3:]
+				continue
+			}
+			return q[end+3:]
+		}
+	}
+	for i := 1; i < len(q); i++ {
+		switch q[i] {
+		case '\\':
+			i++
+		case '"', '\n', '\r':
+			return q[i+1:]
+		}
+	}
+	return ""
+}
+
+// minifyQuery returns q without comments and insignificant whitespace
+// and commas. A single space is kept between two names or numbers, and
+// before negative numbers, and strings and block strings are kept as is.
+func minifyQuery(q string) string {
+	var b strings.Builder
+	b.Grow(len(q))
+	var ignored bool
+	for len(q) > 0 {
+		if rest := skipIgnored(q); len(rest) < len(q) {
+			ignored = true
+			q = rest
+			continue
+		}
+		if ignored && b.Len() > 0 && isWordByte(b.String()[b.Len()-1]) && (isWordByte(q[0]) || q[0] == '-') {
+			b.WriteByte(' ')
+		}
+		ignored = false
+		n := 1
+		if q[0] == '"' {
+			n = len(q) - len(skipString(q))
+		}
+		b.WriteString(q[:n])
+		q = q[n:]
+	}
+	return b.String()
+}
+
+// isWordByte reports whether c can be part of a name or number.
+func isWordByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,93 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestOperationType(t *testing.T) {
+	is := is.New(t)
+	for q, want := range map[string]string{
+		"query { a }":                        "query",
+		"{ a }":                              "query",
+		"  \n\t, query GetUser { a }":        "query",
+		"# comment\nmutation { a }":          "mutation",
+		"# one\r\n# two\nsubscription { a }": "subscription",
+		"\ufeffmutation{ a }":                "mutation",
+		"queryX { a }":                       "",
+		"fragment F on User { a }":           "",
+		"# only a comment":                   "",
+		"":                                   "",
+	} {
+		is.Equal(operationType(q), want) // operationType(q)
+	}
+}
+
+func TestOperationCount(t *testing.T) {
+	is := is.New(t)
+	for q, want := range map[string]int{
+		"":            0,
+		"{ a }":       1,
+		"query { a }": 1,
+		"query A($id: ID = \"{\") { a(id: $id) { b } }": 1,
+		"query A { a } mutation B { b }":                2,
+		"query A { ...F } fragment F on T { a }":        1,
+		"{ a } # query B { b }\n":                       1,
+		`query A { a(s: """ } query B { """) }`:         1,
+		"subscription A { a } query B { b } { c }":      3,
+	} {
+		is.Equal(operationCount(q), want) // operationCount(q)
+	}
+}
+
+func TestSelectedOperationType(t *testing.T) {
+	is := is.New(t)
+	q := "query A { a } mutation B($id: ID) { b } subscription C { c }"
+	is.Equal(selectedOperationType(q, ""), "") // ambiguous
+	is.Equal(selectedOperationType(q, "A"), "query")
+	is.Equal(selectedOperationType(q, "B"), "mutation")
+	is.Equal(selectedOperationType(q, "C"), "subscription")
+	is.Equal(selectedOperationType(q, "D"), "")
+
+	q = "fragment F on User { id } mutation M { deleteAll { ...F } }"
+	is.Equal(selectedOperationType(q, ""), "mutation")
+	is.Equal(selectedOperationType("{ a }", ""), "query")
+}
+
+func TestMinifyQuery(t *testing.T) {
+	is := is.New(t)
+	for q, want := range map[string]string{
+		"": "",
+		`
+			# get a user
+			query GetUser($id: ID!, $first: Int = 10) {
+				user(id: $id) {
+					name, # the full name
+					friends(first: $first) { ...F }
+				}
+			}
+			fragment F on User { name }
+		`: `query GetUser($id:ID!$first:Int=10){user(id:$id){name friends(first:$first){...F}}}fragment F on User{name}`,
+		`{ search(text: "two  spaces, # not a comment") { id } }`:      `{search(text:"two  spaces, # not a comment"){id}}`,
+		"{ a(text: \"\"\"\n  block   \\\"\"\" string\n\"\"\") }":       "{a(text:\"\"\"\n  block   \\\"\"\" string\n\"\"\")}",
+		`{ a(list: [1, 2, -3.5e10], escaped: "quote \" and  space") }`: `{a(list:[1 2 -3.5e10]escaped:"quote \" and  space")}`,
+	} {
+		is.Equal(minifyQuery(q), want)
+	}
+}
+
+func TestFirstOperationName(t *testing.T) {
+	is := is.New(t)
+	for q, want := range map[string]string{
+		"":                        "",
+		"{ user { name } }":       "",
+		"query { user { name } }": "",
+		"query GetUser($id: ID!) { user(id: $id) }": "GetUser",
+		"# comment\nmutation SaveUser { save }":     "SaveUser",
+		"fragment F on User { a } query Q { ...F }": "Q",
+		"{ a } query Second { b }":                  "Second",
+	} {
+		is.Equal(firstOperationName(q), want) // firstOperationName(q)
+	}
+}
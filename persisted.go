@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// WithPersistedQueries enables Automatic Persisted Queries.
+// Run first sends only the SHA-256 hash of the query in the
+// extensions.persistedQuery field of the request. If the server does
+// not know the hash yet, the request is sent again with both the query
+// and its hash so the server can store it.
+//
+// Combined with UseGETForQueries, queries are sent as GET requests with
+// the hash in the extensions query parameter, so that CDNs can cache
+// them by URL without the query text. If the server does not know the
+// hash, the query is sent along with it in a POST request.
+// Persisted queries are only used for JSON requests; they are ignored
+// with the UseMultipartForm option.
+func WithPersistedQueries() ClientOption {
+	return func(client *Client) {
+		client.persistedQueries = true
+	}
+}
+
+// WithPersistedQueryHash sets the function that computes the hash of a
+// query sent with WithPersistedQueries, for servers that identify
+// persisted queries by something other than the hex encoded SHA-256
+// hash, the default. The hash is still sent in the sha256Hash field of
+// the persistedQuery extension. hash must return the same value for
+// the same query, as the hashes are cached.
+func WithPersistedQueryHash(hash func(query string) string) ClientOption {
+	return func(client *Client) {
+		client.queryHashFunc = hash
+	}
+}
+
+// runPersisted executes req using Automatic Persisted Queries.
+func (c *Client) runPersisted(ctx context.Context, req *Request, resp interface{}) (bool, error) {
+	body := newJSONBody(req)
+	body.Query = ""
+	body.Extensions = map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": c.queryHash(req.q),
+		},
+	}
+	var r *http.Request
+	var err error
+	if c.usesGET(req) {
+		r, err = c.newGETRequest(req, body)
+	} else {
+		r, err = c.newJSONRequest(req, body)
+	}
+	if err != nil {
+		return false, err
+	}
+	retry, err := c.send(ctx, r, req, resp)
+	if !isPersistedQueryNotFound(err) {
+		return retry, err
+	}
+	c.logf(">> persisted query not found, sending query")
+	body.Query = req.q
+	if req.method == http.MethodGet {
+		r, err = c.newGETRequest(req, body)
+	} else {
+		r, err = c.newJSONRequest(req, body)
+	}
+	if err != nil {
+		return false, err
+	}
+	return c.send(ctx, r, req, resp)
+}
+
+// queryHash returns the hash of q set with WithPersistedQueryHash, or
+// else its hex encoded SHA-256 hash.
+func (c *Client) queryHash(q string) string {
+	c.queryHashesMu.Lock()
+	defer c.queryHashesMu.Unlock()
+	if hash, ok := c.queryHashes[q]; ok {
+		return hash
+	}
+	var hash string
+	if c.queryHashFunc != nil {
+		hash = c.queryHashFunc(q)
+	} else {
+		sum := sha256.Sum256([]byte(q))
+		hash = hex.EncodeToString(sum[:])
+	}
+	if c.queryHashes == nil {
+		c.queryHashes = make(map[string]string)
+	}
+	c.queryHashes[q] = hash
+	return hash
+}
+
+// isPersistedQueryNotFound reports whether err is the error a server
+// returns when it does not know the hash of a persisted query.
+func isPersistedQueryNotFound(err error) bool {
+	gqlErr, ok := err.(*GraphQLError)
+	if !ok {
+		return false
+	}
+	for _, e := range gqlErr.Errors {
+		if e.Message == "PersistedQueryNotFound" || e.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
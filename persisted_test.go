@@ -0,0 +1,143 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPersistedQueries(t *testing.T) {
+	is := is.New(t)
+	const query = "query { value }"
+	const hash = "ca2da1ce4a4bbd490a5d36ff60dc06cc0d021cde86b426367a8a37a7d0eb89ee"
+	stored := make(map[string]string)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var body struct {
+			Query      string
+			Extensions struct {
+				PersistedQuery struct {
+					Version    int
+					Sha256Hash string
+				}
+			}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal(body.Extensions.PersistedQuery.Version, 1)
+		is.Equal(body.Extensions.PersistedQuery.Sha256Hash, hash)
+		if body.Query != "" {
+			stored[body.Extensions.PersistedQuery.Sha256Hash] = body.Query
+		}
+		if _, ok := stored[body.Extensions.PersistedQuery.Sha256Hash]; !ok {
+			io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound"}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithPersistedQueries())
+	var resp struct {
+		Value string
+	}
+	err := client.Run(ctx, NewRequest(query, srv.URL), &resp)
+	is.NoErr(err)
+	is.Equal(resp.Value, "some data")
+	is.Equal(calls, 2) // hash, then hash and query
+	is.Equal(stored[hash], query)
+
+	err = client.Run(ctx, NewRequest(query, srv.URL), &resp)
+	is.NoErr(err)
+	is.Equal(calls, 3) // hash only
+}
+
+func TestPersistedQueriesGET(t *testing.T) {
+	is := is.New(t)
+	const query = "query { value }"
+	const hash = "ca2da1ce4a4bbd490a5d36ff60dc06cc0d021cde86b426367a8a37a7d0eb89ee"
+	var methods []string
+	var stored bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodGet {
+			is.Equal(r.URL.Query().Get("query"), "")
+			is.Equal(r.URL.Query().Get("variables"), `{"id":1}`)
+			is.Equal(r.URL.Query().Get("extensions"), `{"persistedQuery":{"sha256Hash":"`+hash+`","version":1}}`)
+			if !stored {
+				io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`)
+				return
+			}
+			io.WriteString(w, `{"data":{"value":"cached"}}`)
+			return
+		}
+		var body struct {
+			Query      string
+			Extensions struct {
+				PersistedQuery struct {
+					Sha256Hash string
+				}
+			}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		is.Equal(body.Query, query)
+		is.Equal(body.Extensions.PersistedQuery.Sha256Hash, hash)
+		stored = true
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithPersistedQueries(), UseGETForQueries())
+	var resp struct {
+		Value string
+	}
+	req := NewRequest(query, srv.URL)
+	req.Var("id", 1)
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(resp.Value, "some data")
+	is.Equal(methods, []string{http.MethodGet, http.MethodPost}) // hash, then query
+
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(resp.Value, "cached")
+	is.Equal(methods, []string{http.MethodGet, http.MethodPost, http.MethodGet})
+}
+
+func TestWithPersistedQueryHash(t *testing.T) {
+	is := is.New(t)
+	var hashes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Extensions struct {
+				PersistedQuery struct {
+					Sha256Hash string
+				}
+			}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		hashes = append(hashes, body.Extensions.PersistedQuery.Sha256Hash)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var calls int
+	client := NewClient(WithPersistedQueries(), WithPersistedQueryHash(func(query string) string {
+		calls++
+		return "stub:" + query
+	}))
+	is.NoErr(client.Run(ctx, NewRequest("query { value }", srv.URL), nil))
+	is.NoErr(client.Run(ctx, NewRequest("query { value }", srv.URL), nil))
+	is.Equal(hashes, []string{"stub:query { value }", "stub:query { value }"})
+	is.Equal(calls, 1) // the hash is cached
+}
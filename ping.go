@@ -0,0 +1,26 @@
+package graphql
+
+import "context"
+
+// DefaultPingQuery is the query sent by Ping unless changed with
+// WithPingQuery.
+const DefaultPingQuery = "{__typename}"
+
+// WithPingQuery sets the query sent by Ping.
+func WithPingQuery(q string) ClientOption {
+	return func(client *Client) {
+		client.pingQuery = q
+	}
+}
+
+// Ping checks that the server at the endpoint set with WithEndpoint is
+// reachable and able to execute a trivial query, which makes it
+// suitable for readiness probes. It returns an error if the request
+// fails or the response contains GraphQL errors.
+func (c *Client) Ping(ctx context.Context) error {
+	q := c.pingQuery
+	if q == "" {
+		q = DefaultPingQuery
+	}
+	return c.Run(ctx, NewRequest(q, ""), nil)
+}
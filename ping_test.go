@@ -0,0 +1,52 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPing(t *testing.T) {
+	is := is.New(t)
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		queries = append(queries, body.Query)
+		io.WriteString(w, `{"data":{"__typename":"Query"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	is.NoErr(NewClient(WithEndpoint(srv.URL)).Ping(ctx))
+	is.NoErr(NewClient(WithEndpoint(srv.URL), WithPingQuery("{ health }")).Ping(ctx))
+	is.Equal(queries, []string{"{__typename}", "{ health }"})
+}
+
+func TestPingErrors(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":[{"message":"database unavailable"}]}`)
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithEndpoint(srv.URL))
+	err := client.Ping(ctx)
+	var gqlErr *GraphQLError
+	is.True(errors.As(err, &gqlErr))
+
+	srv.Close()
+	err = client.Ping(ctx)
+	is.True(err != nil) // unreachable
+}
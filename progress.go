@@ -0,0 +1,94 @@
+package graphql
+
+import "io"
+
+// progressChunk is the amount of file data written between calls of
+// the function set with OnUploadProgress.
+const progressChunk = 32 << 10
+
+// OnUploadProgress sets fn to be called as the files of the request
+// are written to the multipart request body, with the number of bytes
+// of the files written so far and their total size, which is -1 if the
+// size of any file is unknown because its reader cannot seek and its
+// Size is not set. fn is called after every write of up to 32KB of file
+// data, rather than for every byte, and starts over from zero when the
+// request is retried. It is called from the goroutine that writes the
+// request body, so it must not block.
+func (req *Request) OnUploadProgress(fn func(bytesWritten, totalBytes int64)) {
+	req.uploadProgress = fn
+}
+
+// uploadProgress reports the progress of writing the files of a
+// request to the function set with OnUploadProgress.
+type uploadProgress struct {
+	fn      func(bytesWritten, totalBytes int64)
+	written int64
+	total   int64
+}
+
+// newUploadProgress returns the progress of writing files, or nil if
+// fn is nil.
+func newUploadProgress(fn func(bytesWritten, totalBytes int64), files []File) *uploadProgress {
+	if fn == nil {
+		return nil
+	}
+	return &uploadProgress{fn: fn, total: uploadSize(files)}
+}
+
+// uploadSize returns the total size of files, or -1 if the size of any
+// of them is unknown.
+func uploadSize(files []File) int64 {
+	var total int64
+	for i := range files {
+		if files[i].Size > 0 {
+			total += files[i].Size
+			continue
+		}
+		seeker, ok := files[i].R.(io.Seeker)
+		if !ok {
+			return -1
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return -1
+		}
+		total += end - offset
+	}
+	return total
+}
+
+// writer returns a writer that writes to w and reports the progress.
+func (p *uploadProgress) writer(w io.Writer) io.Writer {
+	return progressWriter{w: w, p: p}
+}
+
+type progressWriter struct {
+	w io.Writer
+	p *uploadProgress
+}
+
+func (pw progressWriter) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > progressChunk {
+			chunk = chunk[:progressChunk]
+		}
+		m, err := pw.w.Write(chunk)
+		n += m
+		pw.p.written += int64(m)
+		if err != nil {
+			return n, err
+		}
+		pw.p.fn(pw.p.written, pw.p.total)
+		b = b[m:]
+	}
+	return n, nil
+}
@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestOnUploadProgress(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(UseMultipartForm())
+
+	const size = 100 << 10
+	type progress struct{ written, total int64 }
+	var calls []progress
+	req := NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	req.FileVar("variables.file", "a.bin", bytes.NewReader(make([]byte, size)))
+	req.File("b", "b.txt", bytes.NewReader([]byte("small")))
+	req.OnUploadProgress(func(written, total int64) {
+		calls = append(calls, progress{written, total})
+	})
+	is.NoErr(client.Run(ctx, req, nil))
+	is.True(len(calls) > 1)
+	is.True(len(calls) < 10) // not called for every byte
+	is.Equal(calls[len(calls)-1], progress{size + 5, size + 5})
+	for i := 1; i < len(calls); i++ {
+		is.True(calls[i].written > calls[i-1].written)
+	}
+
+	calls = nil
+	req = NewRequest("mutation ($file: Upload!) { upload(file: $file) }", srv.URL)
+	req.FileVar("variables.file", "a.bin", io.LimitReader(zeroReader{}, size))
+	req.OnUploadProgress(func(written, total int64) {
+		calls = append(calls, progress{written, total})
+	})
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(calls[len(calls)-1], progress{size, -1}) // the total is unknown
+}
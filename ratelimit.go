@@ -0,0 +1,28 @@
+package graphql
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimiter makes the Client wait for limiter before sending every
+// HTTP request, including retries, batches, pings, the requests of
+// RunRaw and RunIncremental and the handshakes of subscriptions, to stay
+// under the rate limit of a server.
+// Waiting respects the context of the call: if it is cancelled or its
+// deadline would pass before the limiter allows the request, the call
+// returns an error without sending it.
+func WithRateLimiter(limiter *rate.Limiter) ClientOption {
+	return func(client *Client) {
+		client.limiter = limiter
+	}
+}
+
+// wait blocks until the rate limiter allows a request.
+func (c *Client) wait(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(ctx)
+}
@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"golang.org/x/time/rate"
+)
+
+func TestWithRateLimiter(t *testing.T) {
+	is := is.New(t)
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithRateLimiter(rate.NewLimiter(rate.Every(50*time.Millisecond), 1)))
+	for i := 0; i < 3; i++ {
+		is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), nil))
+	}
+	is.Equal(len(times), 3)
+	for i := 1; i < len(times); i++ {
+		is.True(times[i].Sub(times[i-1]) >= 40*time.Millisecond) // spaced by the limiter
+	}
+}
+
+func TestWithRateLimiterCancel(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithRateLimiter(rate.NewLimiter(rate.Every(time.Hour), 1)))
+	is.NoErr(client.Run(context.Background(), NewRequest("query {}", srv.URL), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	err := client.Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.True(errors.Is(err, context.Canceled))
+	is.True(time.Since(start) < 500*time.Millisecond) // returned promptly
+	is.Equal(calls, 1)
+}
+
+func TestWithRateLimiterAllPaths(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithEndpoint(srv.URL), WithRateLimiter(rate.NewLimiter(rate.Every(time.Hour), 1)))
+	is.NoErr(client.Ping(context.Background()))
+	is.Equal(calls, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	is.True(client.Ping(ctx) != nil) // the limiter does not allow another request
+	_, err := client.Subscribe(ctx, NewRequest("subscription { counter }", ""))
+	is.True(err != nil)
+	_, _, err = client.RunRaw(ctx, NewRequest("query {}", ""))
+	is.True(err != nil)
+	_, err = client.RunIncremental(ctx, NewRequest("query {}", ""))
+	is.True(err != nil)
+	is.True(client.RunBatch(ctx, []*Request{NewRequest("query {}", "")}, []interface{}{nil}) != nil)
+	is.Equal(calls, 1) // nothing was sent
+}
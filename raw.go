@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RunRaw executes the query and returns the body of the response without
+// decoding it, along with the response itself for access to the status
+// code and headers. The body of the returned response has already been
+// read and closed.
+// Unlike Run, RunRaw does not treat a non-2xx status code or a body
+// that is not valid JSON as an error, which makes it useful for
+// inspecting error pages returned by the server. It is never retried
+// and always sends the full query.
+func (c *Client) RunRaw(ctx context.Context, req *Request) ([]byte, *http.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	r, err := c.BuildRequest(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := c.roundTrip(ctx, c.httpClientFor(req), r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	req.captureHeader(res)
+	if err := c.afterReceive(ctx, res); err != nil {
+		return nil, nil, err
+	}
+	body, err := ioutil.ReadAll(limitResponse(res.Body, c.maxResponseBytes))
+	if err == ErrResponseTooLarge {
+		return nil, res, err
+	}
+	if err != nil {
+		return nil, res, &NetworkError{Err: errors.Wrap(err, "reading body")}
+	}
+	c.logf("<< %s", body)
+	return body, res, nil
+}
+
+// BuildRequest returns the HTTP request Run would send for req, with
+// its headers and body, without sending it. It is useful to inspect
+// the encoded query and variables, or to send the request with another
+// http.Client. Every call builds a new request with a fresh body.
+// Like RunRaw, BuildRequest always includes the full query.
+func (c *Client) BuildRequest(ctx context.Context, req *Request) (*http.Request, error) {
+	req, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.files) > 0 && !c.useMultipartForm {
+		return nil, errors.New("cannot send files with PostFields option")
+	}
+	r, err := c.newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.prepareRequest(ctx, r, req.Header)
+}
@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunRaw(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `<html><body>upstream connect error</body></html>`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	body, res, err := client.RunRaw(ctx, NewRequest("query {}", srv.URL))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, http.StatusInternalServerError)
+	is.Equal(res.Header.Get("Content-Type"), "text/html")
+	is.Equal(string(body), `<html><body>upstream connect error</body></html>`)
+}
+
+func TestBuildRequest(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(WithBearerToken("secret"))
+	req := NewRequest("query ($id: ID!) { item(id: $id) { name } }", "https://example.com/graphql")
+	req.Var("id", "1")
+	req.Header.Set("X-Custom", "value")
+
+	for i := 0; i < 2; i++ {
+		r, err := client.BuildRequest(context.Background(), req)
+		is.NoErr(err)
+		is.Equal(r.Method, http.MethodPost)
+		is.Equal(r.URL.String(), "https://example.com/graphql")
+		is.Equal(r.Header.Get("Content-Type"), "application/json; charset=utf-8")
+		is.Equal(r.Header.Get("Authorization"), "Bearer secret")
+		is.Equal(r.Header.Get("X-Custom"), "value")
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		is.Equal(string(b), `{"query":"query ($id: ID!) { item(id: $id) { name } }","variables":{"id":"1"}}`+"\n")
+	}
+}
+
+func TestBuildRequestMultipart(t *testing.T) {
+	is := is.New(t)
+	client := NewClient(UseMultipartForm())
+	req := NewRequest("query {}", "https://example.com/graphql")
+	req.File("file", "filename.txt", strings.NewReader("some file"))
+	r, err := client.BuildRequest(context.Background(), req)
+	is.NoErr(err)
+	is.NoErr(r.ParseMultipartForm(1024))
+	is.Equal(r.FormValue("query"), "query {}")
+	is.Equal(len(r.MultipartForm.File["file"]), 1)
+}
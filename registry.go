@@ -0,0 +1,59 @@
+package graphql
+
+import "context"
+
+// WithRegisteredOperations makes Run send only the ID of requests that
+// have one set with Request.RegisteredOperationID, in the given header,
+// instead of their query, for servers that execute the operations of a
+// registered operation manifest. If the server rejects the ID as
+// unknown, with an error of code PERSISTED_QUERY_NOT_FOUND or
+// PERSISTED_QUERY_NOT_IN_LIST, the request is sent again with its full
+// query and without the ID.
+// Registered operations are only used for JSON requests; they are
+// ignored with the UseMultipartForm option. They take precedence over
+// WithPersistedQueries.
+//
+//	client := graphql.NewClient(graphql.WithRegisteredOperations("X-Operation-ID"))
+//	req.RegisteredOperationID("GetUser@3f2a")
+func WithRegisteredOperations(header string) ClientOption {
+	return func(client *Client) {
+		client.operationIDHeader = header
+	}
+}
+
+// RegisteredOperationID sets the ID the operation of the request is
+// registered under on the server, see WithRegisteredOperations.
+func (req *Request) RegisteredOperationID(id string) {
+	req.operationID = id
+}
+
+// runRegistered executes req by sending its registered operation ID.
+func (c *Client) runRegistered(ctx context.Context, req *Request, resp interface{}) (bool, error) {
+	body := newJSONBody(req)
+	body.Query = ""
+	r, err := c.newJSONRequest(req, body)
+	if err != nil {
+		return false, err
+	}
+	r.Header.Set(c.operationIDHeader, req.operationID)
+	retry, err := c.send(ctx, r, req, resp)
+	if !isOperationNotRegistered(err) {
+		return retry, err
+	}
+	c.logf(">> registered operation %s not found, sending query", req.operationID)
+	r, err = c.newJSONRequest(req, newJSONBody(req))
+	if err != nil {
+		return false, err
+	}
+	return c.send(ctx, r, req, resp)
+}
+
+// isOperationNotRegistered reports whether err is the error a server
+// returns when it does not know a registered operation ID.
+func isOperationNotRegistered(err error) bool {
+	if isPersistedQueryNotFound(err) {
+		return true
+	}
+	gqlErr, ok := err.(*GraphQLError)
+	return ok && gqlErr.HasCode("PERSISTED_QUERY_NOT_IN_LIST")
+}
@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRegisteredOperations(t *testing.T) {
+	is := is.New(t)
+	const query = "query GetUser($id: ID!) { user(id: $id) { name } }"
+	type call struct {
+		operationID, query string
+		variables          map[string]interface{}
+	}
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		id := r.Header.Get("X-Operation-ID")
+		calls = append(calls, call{operationID: id, query: body.Query, variables: body.Variables})
+		if id != "" && id != "GetUser@1" {
+			io.WriteString(w, `{"errors":[{"message":"not in the manifest","extensions":{"code":"PERSISTED_QUERY_NOT_IN_LIST"}}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"user":{"name":"Mat"}}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithRegisteredOperations("X-Operation-ID"))
+	var resp struct {
+		User struct{ Name string }
+	}
+	req := NewRequest(query, srv.URL)
+	req.RegisteredOperationID("GetUser@1")
+	req.Var("id", "1")
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(resp.User.Name, "Mat")
+	is.Equal(calls, []call{{operationID: "GetUser@1", variables: map[string]interface{}{"id": "1"}}}) // the ID only
+
+	calls = nil
+	req.RegisteredOperationID("GetUser@2")
+	is.NoErr(client.Run(ctx, req, &resp))
+	is.Equal(calls, []call{
+		{operationID: "GetUser@2", variables: map[string]interface{}{"id": "1"}},
+		{query: query, variables: map[string]interface{}{"id": "1"}}, // the full query as a fallback
+	})
+
+	calls = nil
+	is.NoErr(client.Run(ctx, NewRequest(query, srv.URL), &resp))
+	is.Equal(calls, []call{{query: query}}) // requests without an ID send the query
+}
@@ -0,0 +1,310 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestNewRequestFromReader(t *testing.T) {
+	is := is.New(t)
+	req, err := NewRequestFromReader(strings.NewReader("query { value }"), "https://example.com/graphql")
+	is.NoErr(err)
+	is.Equal(req.Query(), "query { value }")
+	is.Equal(req.Endpoint, "https://example.com/graphql")
+
+	_, err = NewRequestFromReader(errReader{errors.New("broken")}, "")
+	is.Equal(err.Error(), "read query: broken")
+}
+
+func TestNewRequestFromFS(t *testing.T) {
+	is := is.New(t)
+	fsys := fstest.MapFS{
+		"queries/user.graphql": &fstest.MapFile{Data: []byte("query { user { name } }")},
+	}
+	req, err := NewRequestFromFS(fsys, "queries/user.graphql", "https://example.com/graphql")
+	is.NoErr(err)
+	is.Equal(req.Query(), "query { user { name } }")
+
+	_, err = NewRequestFromFS(fsys, "queries/missing.graphql", "")
+	is.True(err != nil)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestVarStruct(t *testing.T) {
+	is := is.New(t)
+	type Address struct {
+		City    string  `json:"city"`
+		Country *string `json:"country,omitempty"`
+	}
+	type Input struct {
+		Name     string   `json:"name"`
+		Nickname string   `json:"nickname,omitempty"`
+		Age      int64    `json:"age"`
+		Address  *Address `json:"address"`
+		Manager  *Address `json:"manager"`
+	}
+	req := NewRequest("mutation {}", "")
+	req.Var("name", "overwritten")
+	req.Var("extra", true)
+	req.VarStruct(Input{
+		Name:    "matryer",
+		Age:     9007199254740993,
+		Address: &Address{City: "London"},
+	})
+	req.Var("age", 42)
+
+	b, err := json.Marshal(req.Vars())
+	is.NoErr(err)
+	is.Equal(string(b), `{"address":{"city":"London"},"age":42,"extra":true,"manager":null,"name":"matryer"}`)
+
+	req.VarStruct(Input{Age: 9007199254740993})
+	b, err = json.Marshal(req.Vars()["age"])
+	is.NoErr(err)
+	is.Equal(string(b), `9007199254740993`)
+}
+
+func TestVarStructError(t *testing.T) {
+	is := is.New(t)
+	req := NewRequest("mutation {}", "")
+	req.VarStruct(map[string]interface{}{"fn": func() {}})
+	err := NewClient().Run(context.Background(), req, nil)
+	is.Equal(err.Error(), "marshal variables: json: unsupported type: func()")
+}
+
+func TestVarMap(t *testing.T) {
+	is := is.New(t)
+	req := NewRequest("query {}", "")
+	req.Var("limit", 10)
+	req.VarMap(map[string]interface{}{"name": "Mat", "limit": 20})
+	req.VarMap(map[string]interface{}{"name": "David", "active": true})
+	req.VarMap(nil)
+	req.VarMap(map[string]interface{}{})
+	is.Equal(req.Vars(), map[string]interface{}{"name": "David", "limit": 20, "active": true})
+
+	req = NewRequest("query {}", "")
+	req.VarMap(nil)
+	is.Equal(req.Vars(), nil) // no-op
+}
+
+func TestVarNull(t *testing.T) {
+	is := is.New(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		body = string(b)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient()
+
+	req := NewRequest("query {}", srv.URL)
+	req.Var("nil", nil) // sent as null
+	req.VarNull("null")
+	req.Var("value", 1)
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(body, `{"query":"query {}","variables":{"nil":null,"null":null,"value":1}}`+"\n")
+
+	req.DelVar("nil") // unsets a variable
+	req.DelVar("null")
+	req.DelVar("unknown")
+	req.VarNull("value")
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(body, `{"query":"query {}","variables":{"value":null}}`+"\n")
+
+	var nilPointer *int
+	req.Var("value", nilPointer) // typed nil values are sent as null
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(body, `{"query":"query {}","variables":{"value":null}}`+"\n")
+}
+
+func TestVarFunc(t *testing.T) {
+	is := is.New(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		is.NoErr(err)
+		body = string(b)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	since := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	req := NewRequest("query ($since: Int!) {}", srv.URL)
+	req.Var("name", "value")
+	req.VarFunc("since", func() (interface{}, error) {
+		return since.Unix(), nil
+	})
+	err := NewClient().Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(body, `{"query":"query ($since: Int!) {}","variables":{"name":"value","since":1577934245}}`+"\n")
+}
+
+func TestVarFuncError(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	req := NewRequest("query ($since: Int!) {}", srv.URL)
+	req.VarFunc("since", func() (interface{}, error) {
+		return nil, errors.New("no time")
+	})
+	err := NewClient().Run(context.Background(), req, nil)
+	is.Equal(err.Error(), "variable since: no time")
+	is.Equal(calls, 0)
+}
+
+func TestMethod(t *testing.T) {
+	is := is.New(t)
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodGet {
+			is.Equal(r.URL.Query().Get("query"), "query {}")
+		} else {
+			b, err := ioutil.ReadAll(r.Body)
+			is.NoErr(err)
+			is.Equal(string(b), `{"query":"query {}","variables":null}`+"\n")
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, tt := range []struct {
+		client *Client
+		method string
+	}{
+		{client: NewClient(), method: http.MethodPut},
+		{client: NewClient(), method: http.MethodGet},
+		{client: NewClient(UseGETForQueries()), method: http.MethodPost},
+	} {
+		req := NewRequest("query {}", srv.URL)
+		req.Method(tt.method)
+		err := tt.client.Run(ctx, req, nil)
+		is.NoErr(err)
+	}
+	is.Equal(methods, []string{http.MethodPut, http.MethodGet, http.MethodPost})
+}
+
+func TestMethodUnknown(t *testing.T) {
+	is := is.New(t)
+	req := NewRequest("query {}", "https://example.com/graphql")
+	req.Method("FETCH")
+	err := NewClient().Run(context.Background(), req, nil)
+	is.Equal(err.Error(), `graphql: unknown HTTP method "FETCH"`)
+}
+
+func TestClone(t *testing.T) {
+	is := is.New(t)
+	req := NewRequest("query ($id: ID!) { user(id: $id) { name } }", "https://example.com/graphql")
+	req.Var("id", "1")
+	req.Header.Set("X-Custom", "original")
+	req.OperationName("GetUser")
+
+	clone := req.Clone()
+	clone.Var("id", "2")
+	clone.Var("extra", true)
+	clone.Header.Set("X-Custom", "clone")
+	is.Equal(clone.Query(), req.Query())
+	is.Equal(clone.operationName, "GetUser")
+	is.Equal(clone.Vars(), map[string]interface{}{"id": "2", "extra": true})
+	is.Equal(req.Vars(), map[string]interface{}{"id": "1"}) // original is unchanged
+	is.Equal(req.Header.Get("X-Custom"), "original")        // original is unchanged
+
+	clone.ResetVars()
+	is.Equal(len(clone.Vars()), 0)
+	is.Equal(req.Vars(), map[string]interface{}{"id": "1"})
+}
+
+func TestCloneConcurrent(t *testing.T) {
+	is := is.New(t)
+	var mu sync.Mutex
+	ids := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]string
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		ids[body.Variables["id"]] = true
+		mu.Unlock()
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	base := NewRequest("query ($id: ID!) { user(id: $id) { name } }", srv.URL)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := base.Clone()
+			req.VarID("id", i)
+			req.Header.Set("X-Index", strconv.Itoa(i))
+			is.NoErr(client.Run(context.Background(), req, nil))
+		}(i)
+	}
+	wg.Wait()
+	is.Equal(len(ids), 10)
+	is.Equal(len(base.Vars()), 0)
+}
+
+func TestRequestWithHTTPClient(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			is.NoErr(client.Run(ctx, NewRequest("query {}", srv.URL), nil))
+		}()
+	}
+	req := NewRequest("query {}", srv.URL)
+	req.WithHTTPClient(&http.Client{Timeout: 10 * time.Millisecond})
+	err := client.Run(ctx, req, nil)
+	var netErr *NetworkError
+	is.True(errors.As(err, &netErr)) // the request times out
+	wg.Wait()
+
+	req = req.Clone()
+	is.True(req.httpClient != nil) // clones keep the override
+}
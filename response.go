@@ -0,0 +1,55 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Response is the whole response to a request run with RunResponse.
+type Response struct {
+	// Data is the data field of the response, or nil if it had none.
+	Data json.RawMessage
+	// Errors holds the GraphQL errors of the response.
+	Errors []ErrorEntry
+	// Extensions is the extensions field of the response.
+	Extensions map[string]interface{}
+}
+
+// RunResponse executes req like Run, but returns the whole response
+// rather than unmarshaling its data. GraphQL errors in the response
+// are returned in its Errors field instead of as an error; the error
+// reports failures to get a response, such as an *HTTPError or a
+// *NetworkError. The destination set with req.ResponseExtensions is
+// not used.
+func (c *Client) RunResponse(ctx context.Context, req *Request) (*Response, error) {
+	resp := &Response{}
+	withExtensions := *req
+	withExtensions.extensions = &resp.Extensions
+	err := c.Run(ctx, &withExtensions, &resp.Data)
+	var gqlErr *GraphQLError
+	if errors.As(err, &gqlErr) {
+		resp.Errors = gqlErr.Errors
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Decode unmarshals the data of the response into v. If the response
+// carries GraphQL errors, a *GraphQLError is returned after v has been
+// filled.
+func (r *Response) Decode(v interface{}) error {
+	if len(r.Data) > 0 {
+		if err := json.Unmarshal(r.Data, v); err != nil {
+			return errors.Wrap(err, "decoding data")
+		}
+	}
+	if len(r.Errors) > 0 {
+		return &GraphQLError{Errors: r.Errors}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunResponse(t *testing.T) {
+	is := is.New(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient()
+
+	body = `{"data":{"user":{"name":"Mat"}},"errors":[{"message":"partial","extensions":{"code":"PARTIAL"}}],"extensions":{"cost":3}}`
+	resp, err := client.RunResponse(ctx, NewRequest("query {}", srv.URL))
+	is.NoErr(err) // GraphQL errors are part of the response
+	is.Equal(string(resp.Data), `{"user":{"name":"Mat"}}`)
+	is.Equal(len(resp.Errors), 1)
+	is.Equal(resp.Errors[0].Code(), "PARTIAL")
+	is.Equal(resp.Extensions["cost"], float64(3))
+	var data struct {
+		User struct {
+			Name string
+		}
+	}
+	err = resp.Decode(&data)
+	is.Equal(err.Error(), "graphql: partial")
+	is.Equal(data.User.Name, "Mat")
+
+	body = `{"data":null,"errors":[{"message":"not found"}]}`
+	resp, err = client.RunResponse(ctx, NewRequest("query {}", srv.URL))
+	is.NoErr(err)
+	is.Equal(resp.Data, nil)
+	is.Equal(resp.Errors[0].Message, "not found")
+
+	body = `not json`
+	_, err = client.RunResponse(ctx, NewRequest("query {}", srv.URL))
+	is.True(IsDecodeError(err))
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WithRetry makes the Client retry a request up to maxAttempts times in
+// total when it fails with a *NetworkError or a retryable status code
+// (502, 503 and 504 unless changed with WithRetryStatus). Decode errors
+// are never retried, and GraphQL errors only with the codes set with
+// WithRetryOnGraphQLError.
+// backoff is called with the number of the attempt that just failed
+// (starting at 1) and returns how long to wait before the next one.
+// A nil backoff waits 100ms, doubling after every attempt up to 30s.
+//
+// Retries never outlive the context passed to Run. Requests with files
+// are only retried when every file reader is an io.Seeker, in which case
+// the readers are rewound before each new attempt; retries are disabled
+// for uploads from other readers, such as network streams, which cannot
+// be read again.
+// Mutations are only retried when they carry an idempotency key, see
+// Request.IdempotencyKey, so that a mutation the server executed
+// before the failure is not executed twice.
+//
+//	NewClient(WithRetry(3, nil))
+func WithRetry(maxAttempts int, backoff func(attempt int) time.Duration) ClientOption {
+	return func(client *Client) {
+		client.maxAttempts = maxAttempts
+		client.backoff = backoff
+		if client.backoff == nil {
+			client.backoff = exponentialBackoff
+		}
+		if client.retryStatus == nil {
+			WithRetryStatus(http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout)(client)
+		}
+	}
+}
+
+// WithRetryStatus sets the HTTP status codes that are retried when
+// WithRetry is used.
+func WithRetryStatus(codes ...int) ClientOption {
+	return func(client *Client) {
+		client.retryStatus = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			client.retryStatus[code] = true
+		}
+	}
+}
+
+// WithRetryOnGraphQLError makes the Client also retry requests whose
+// response carries a GraphQL error with one of the given codes in its
+// extensions, such as "SERVICE_UNAVAILABLE", for servers that report
+// transient failures that way. GraphQL errors with other codes are not
+// retried. The number of attempts and the backoff are set with
+// WithRetry, without which nothing is retried.
+func WithRetryOnGraphQLError(codes ...string) ClientOption {
+	return func(client *Client) {
+		client.retryCodes = make(map[string]bool, len(codes))
+		for _, code := range codes {
+			client.retryCodes[code] = true
+		}
+	}
+}
+
+// retryGraphQLError reports whether err carries a GraphQL error whose
+// code is retried, see WithRetryOnGraphQLError.
+func (c *Client) retryGraphQLError(err error) bool {
+	var gqlErr *GraphQLError
+	if len(c.retryCodes) == 0 || !errors.As(err, &gqlErr) {
+		return false
+	}
+	for _, e := range gqlErr.Errors {
+		if c.retryCodes[e.Code()] {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBackoff caps the waits of exponentialBackoff.
+const maxBackoff = 30 * time.Second
+
+// exponentialBackoff waits 100ms after the first attempt, doubling after
+// every attempt up to maxBackoff.
+func exponentialBackoff(attempt int) time.Duration {
+	// 100ms << 9 is past maxBackoff, and larger shifts overflow
+	if attempt > 10 {
+		return maxBackoff
+	}
+	if d := 100 * time.Millisecond << uint(attempt-1); d < maxBackoff {
+		return d
+	}
+	return maxBackoff
+}
+
+// runWithRetry executes req until it succeeds, fails in a way that is
+// not worth retrying, or the attempts allowed by WithRetry and
+// WithEndpoints are used up. Attempts go to the endpoints of req in turn,
+// with the backoff applied before starting over at the first endpoint.
+func (c *Client) runWithRetry(ctx context.Context, req *Request, resp interface{}) error {
+	endpoints := c.endpointsFor(req)
+	maxAttempts := c.maxAttempts
+	if maxAttempts < len(endpoints) {
+		maxAttempts = len(endpoints)
+	}
+	var failures []EndpointError
+	offsets, seekable := fileOffsets(req.files)
+	resendable := seekable && isIdempotent(req)
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if len(endpoints) > 1 {
+			withEndpoint := *req
+			withEndpoint.Endpoint = endpoints[(attempt-1)%len(endpoints)]
+			attemptReq = &withEndpoint
+		}
+		retry, err := c.runOnce(ctx, attemptReq, resp)
+		if retry && len(endpoints) > 1 {
+			failures = append(failures, EndpointError{Endpoint: attemptReq.Endpoint, Err: err})
+		}
+		if !retry || attempt >= maxAttempts || !resendable {
+			if retry && len(failures) > 1 {
+				return &FailoverError{Errors: failures}
+			}
+			return err
+		}
+		c.logf(">> attempt %d failed: %s", attempt, err)
+		if c.logger != nil {
+			c.logger.Info("graphql retrying request", "endpoint", attemptReq.Endpoint, "attempt", attempt, "error", err)
+		}
+		if attempt%len(endpoints) == 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(c.backoff(attempt / len(endpoints))):
+			}
+		} else if ctx.Err() != nil {
+			return err
+		}
+		if err := rewindFiles(req.files, offsets); err != nil {
+			return err
+		}
+	}
+}
+
+// isIdempotent reports whether req can be sent again after a failure
+// without repeating side effects on the server: queries can, mutations
+// and operations of unknown type only with an idempotency key.
+func isIdempotent(req *Request) bool {
+	return selectedOperationType(req.q, req.operationName) == "query" || req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// fileOffsets records the current offset of every file reader. It
+// reports false if any of the readers cannot seek.
+func fileOffsets(files []File) ([]int64, bool) {
+	offsets := make([]int64, len(files))
+	for i := range files {
+		seeker, ok := files[i].R.(io.Seeker)
+		if !ok {
+			return nil, false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, false
+		}
+		offsets[i] = offset
+	}
+	return offsets, true
+}
+
+func rewindFiles(files []File, offsets []int64) error {
+	for i := range files {
+		if _, err := files[i].R.(io.Seeker).Seek(offsets[i], io.SeekStart); err != nil {
+			return errors.Wrap(err, "rewind file")
+		}
+	}
+	return nil
+}
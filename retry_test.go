@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRetry(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			switch calls {
+			case 1:
+				return nil, errors.New("connection reset by peer")
+			case 2:
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       ioutil.NopCloser(strings.NewReader(`Service Unavailable`)),
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data":{"value":"some data"}}`)),
+			}, nil
+		}),
+	}
+	var attempts []int
+	backoff := func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	}
+	client := NewClient(WithHTTPClient(testClient), WithRetry(3, backoff))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	var resp struct {
+		Value string
+	}
+	err := client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), &resp)
+	is.NoErr(err)
+	is.Equal(calls, 3)
+	is.Equal(attempts, []int{1, 2})
+	is.Equal(resp.Value, "some data")
+}
+
+func TestRetryOnGraphQLError(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	responses := []string{
+		`{"errors":[{"message":"try again","extensions":{"code":"SERVICE_UNAVAILABLE"}}]}`,
+		`{"data":{"value":"some data"}}`,
+		`{"errors":[{"message":"not allowed","extensions":{"code":"FORBIDDEN"}}]}`,
+	}
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(responses[(calls-1)%len(responses)])),
+			}, nil
+		}),
+	}
+	backoff := func(int) time.Duration { return time.Millisecond }
+	client := NewClient(WithHTTPClient(testClient), WithRetry(3, backoff), WithRetryOnGraphQLError("SERVICE_UNAVAILABLE"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	var resp struct {
+		Value string
+	}
+	err := client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), &resp)
+	is.NoErr(err)
+	is.Equal(calls, 2) // retried after SERVICE_UNAVAILABLE
+	is.Equal(resp.Value, "some data")
+
+	err = client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), &resp)
+	is.Equal(err.Error(), "graphql: not allowed")
+	is.Equal(calls, 3) // other codes are not retried
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{
+				StatusCode: http.StatusBadGateway,
+				Body:       ioutil.NopCloser(strings.NewReader(`Bad Gateway`)),
+			}, nil
+		}),
+	}
+	client := NewClient(WithHTTPClient(testClient), WithRetry(3, func(int) time.Duration { return 0 }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	err := client.Run(ctx, NewRequest("query {}", "http://example.com/graphql"), nil)
+	is.Equal(err.Error(), "graphql: server returned a non-200 status code: 502")
+	is.Equal(calls, 3)
+}
+
+func TestRetryFiles(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	var bodies []string
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			is.NoErr(req.ParseMultipartForm(1024))
+			file, _, err := req.FormFile("file")
+			is.NoErr(err)
+			b, err := ioutil.ReadAll(file)
+			is.NoErr(err)
+			bodies = append(bodies, string(b))
+			if calls == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data":{}}`)),
+			}, nil
+		}),
+	}
+	client := NewClient(WithHTTPClient(testClient), UseMultipartForm(), WithRetry(3, func(int) time.Duration { return 0 }))
+	ctx := context.Background()
+
+	// seekable readers are rewound between attempts
+	req := NewRequest("query {}", "http://example.com/graphql")
+	req.File("file", "filename.txt", strings.NewReader("This is a file"))
+	err := client.Run(ctx, req, nil)
+	is.NoErr(err)
+	is.Equal(bodies, []string{"This is a file", "This is a file"})
+
+	// other readers are not retried
+	calls = 0
+	req = NewRequest("query {}", "http://example.com/graphql")
+	req.File("file", "filename.txt", ioutil.NopCloser(strings.NewReader("This is a file")))
+	err = client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.Equal(calls, 1)
+}
+
+func TestRetryMutationIdempotencyKey(t *testing.T) {
+	is := is.New(t)
+	var keys []string
+	testClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			keys = append(keys, req.Header.Get("Idempotency-Key"))
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       ioutil.NopCloser(strings.NewReader(`Service Unavailable`)),
+			}, nil
+		}),
+	}
+	client := NewClient(WithHTTPClient(testClient), WithRetry(3, func(int) time.Duration { return 0 }))
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	// mutations without a key are not retried
+	err := client.Run(ctx, NewRequest(`mutation { createUser(name: "Mat") }`, "http://example.com/graphql"), nil)
+	is.True(IsHTTPError(err))
+	is.Equal(keys, []string{""})
+
+	keys = nil
+	err = client.Run(ctx, NewRequest(`fragment F on User { id } mutation { createUser(name: "Mat") { ...F } }`, "http://example.com/graphql"), nil)
+	is.True(IsHTTPError(err))
+	is.Equal(keys, []string{""}) // nor are mutations after a fragment
+
+	keys = nil
+	req := NewRequest(`mutation { createUser(name: "Mat") }`, "http://example.com/graphql")
+	req.IdempotencyKey("6f1c2e")
+	err = client.Run(ctx, req, nil)
+	is.True(IsHTTPError(err))
+	is.Equal(keys, []string{"6f1c2e", "6f1c2e", "6f1c2e"}) // the same key with every attempt
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	is := is.New(t)
+	is.Equal(exponentialBackoff(1), 100*time.Millisecond)
+	is.Equal(exponentialBackoff(2), 200*time.Millisecond)
+	is.Equal(exponentialBackoff(9), 25600*time.Millisecond)
+	is.Equal(exponentialBackoff(10), 30*time.Second) // capped
+	for _, attempt := range []int{11, 38, 64, 100, 1 << 20} {
+		is.Equal(exponentialBackoff(attempt), 30*time.Second) // no overflow
+	}
+}
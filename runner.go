@@ -0,0 +1,25 @@
+package graphql
+
+import "context"
+
+// Runner runs GraphQL requests. It is implemented by *Client; code
+// that depends on a Runner rather than a *Client can be tested with a
+// fake implementation:
+//
+//	type fakeRunner struct{}
+//
+//	func (fakeRunner) Run(ctx context.Context, req *graphql.Request, resp interface{}) error {
+//	    return json.Unmarshal([]byte(`{"user":{"name":"Mat"}}`), resp)
+//	}
+type Runner interface {
+	Run(ctx context.Context, req *Request, resp interface{}) error
+}
+
+// BatchRunner is a Runner that can also send several requests at once,
+// see Client.RunBatch.
+type BatchRunner interface {
+	Runner
+	RunBatch(ctx context.Context, reqs []*Request, resps []interface{}) error
+}
+
+var _ BatchRunner = (*Client)(nil)
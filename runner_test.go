@@ -0,0 +1,39 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+type fakeRunner struct {
+	reqs []*Request
+}
+
+func (f *fakeRunner) Run(ctx context.Context, req *Request, resp interface{}) error {
+	f.reqs = append(f.reqs, req)
+	return json.Unmarshal([]byte(`{"name":"Mat"}`), resp)
+}
+
+// userName is code under test that depends on a Runner.
+func userName(ctx context.Context, r Runner, id string) (string, error) {
+	req := NewRequest("query ($id: ID!) { user(id: $id) { name } }", "")
+	req.Var("id", id)
+	var resp struct {
+		Name string
+	}
+	err := r.Run(ctx, req, &resp)
+	return resp.Name, err
+}
+
+func TestRunner(t *testing.T) {
+	is := is.New(t)
+	fake := &fakeRunner{}
+	name, err := userName(context.Background(), fake, "1")
+	is.NoErr(err)
+	is.Equal(name, "Mat")
+	is.Equal(len(fake.reqs), 1)
+	is.Equal(fake.reqs[0].Vars()["id"], "1")
+}
@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// runShared executes the query req through the response cache and the
+// singleflight group of the Client, whichever are enabled.
+func (c *Client) runShared(ctx context.Context, req *Request, resp interface{}) error {
+	header, err := c.credentialHeaders(ctx, req)
+	if err != nil {
+		return err
+	}
+	key, err := requestKey(req, header)
+	if err != nil {
+		return err
+	}
+	if c.cache != nil {
+		if data, ok := c.cache.get(key); ok {
+			c.logf("<< cached: %s", data)
+			return c.decodeShared(data, req, resp, nil)
+		}
+	}
+	fetch := func() (interface{}, error) {
+		var data json.RawMessage
+		err := c.do(ctx, req, &data)
+		if err == nil && c.cache != nil {
+			c.cache.add(key, req.Endpoint, data)
+		}
+		return data, err
+	}
+	var v interface{}
+	if c.flights != nil {
+		v, err, _ = c.flights.Do(key, fetch)
+	} else {
+		v, err = fetch()
+	}
+	return c.decodeShared(v.(json.RawMessage), req, resp, err)
+}
+
+// decodeShared unmarshals data shared between calls into resp, and
+// returns err, the error of the call that fetched it.
+func (c *Client) decodeShared(data json.RawMessage, req *Request, resp interface{}, err error) error {
+	if len(data) > 0 && resp != nil {
+		if err := c.decodeData(data, resp); err != nil {
+			return &DecodeError{Err: err}
+		}
+	}
+	if len(data) > 0 && !IsGraphQLError(err) {
+		if err := req.checkTypenames(data); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// credentialHeaders returns the headers of req that can differ between
+// calls to Run for the same Request: the headers set on the Request,
+// those set with WithContextHeader and by the authentication options,
+// and the cookies of the cookie jar. They are part of the key of a
+// request, so that calls on behalf of different users never share a
+// response.
+func (c *Client) credentialHeaders(ctx context.Context, req *Request) (http.Header, error) {
+	u, err := url.Parse(req.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	r := &http.Request{URL: u, Header: make(http.Header)}
+	c.setContextHeaders(ctx, r.Header)
+	if c.authorize != nil {
+		if err := c.authorize(ctx, r); err != nil {
+			return nil, errors.Wrap(err, "authorize")
+		}
+	}
+	copyHeader(r.Header, req.Header)
+	if jar := c.httpClientFor(req).Jar; jar != nil {
+		for _, cookie := range jar.Cookies(u) {
+			r.AddCookie(cookie)
+		}
+	}
+	return r.Header, nil
+}
+
+// requestKey returns the key identical requests share in the response
+// cache and the singleflight group, given the headers returned by
+// credentialHeaders.
+func requestKey(req *Request, header http.Header) (string, error) {
+	b, err := json.Marshal(struct {
+		Endpoint      string
+		Query         string
+		OperationName string
+		Variables     map[string]interface{}
+		Header        map[string][]string
+	}{
+		Endpoint:      req.Endpoint,
+		Query:         req.q,
+		OperationName: req.operationName,
+		Variables:     req.vars,
+		Header:        header,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "encode variables")
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,21 @@
+package graphql
+
+import "golang.org/x/sync/singleflight"
+
+// WithSingleFlight makes concurrent calls to Run with identical queries
+// share a single request: while a query is in flight, calls with the
+// same endpoint, query, operation name, variables and headers wait for
+// its response instead of sending their own, and each unmarshals the
+// shared data into its own response object. The headers include the
+// credentials set by the authentication options, the headers set with
+// WithContextHeader and the cookies of the cookie jar, so calls made on
+// behalf of different users never share a request.
+// Only queries are deduplicated; mutations and requests with files are
+// always sent. The shared request uses the context of the call that
+// sent it, and the extensions of its response are only unmarshaled for
+// that call.
+func WithSingleFlight() ClientOption {
+	return func(client *Client) {
+		client.flights = &singleflight.Group{}
+	}
+}
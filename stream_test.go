@@ -0,0 +1,143 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithStreamingResponses(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{
+			"data": {"user": {"name": "matryer", "email": null}},
+			"errors": [{"message": "not allowed", "path": ["user", "email"]}],
+			"extensions": {"cost": 3}
+		}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	client := NewClient(WithStreamingResponses())
+	req := NewRequest("query { user { name email } }", srv.URL)
+	var extensions map[string]interface{}
+	req.ResponseExtensions(&extensions)
+	var resp struct {
+		User struct {
+			Name  string
+			Email *string
+		}
+	}
+	err := client.Run(ctx, req, &resp)
+	var gqlErr *GraphQLError
+	is.True(errors.As(err, &gqlErr))
+	is.Equal(gqlErr.Errors[0].Message, "not allowed")
+	is.Equal(resp.User.Name, "matryer")
+	is.Equal(extensions["cost"], float64(3))
+}
+
+func TestWithStreamingResponsesNoData(t *testing.T) {
+	is := is.New(t)
+	for _, body := range []string{`{"data": null}`, `{}`} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body)
+		}))
+		client := NewClient(WithStreamingResponses(), WithStrictNoData())
+		var resp map[string]interface{}
+		err := client.Run(context.Background(), NewRequest("query {}", srv.URL), &resp)
+		srv.Close()
+		is.Equal(err, ErrNoData) // body
+		is.Equal(resp, nil)      // body
+	}
+}
+
+func TestWithStreamingResponsesDecodeError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data": {"items": [`+strings.Repeat(`"item",`, 1000))
+	}))
+	defer srv.Close()
+
+	logger := &testLogger{}
+	client := NewClient(WithStreamingResponses(), WithLogger(logger))
+	var resp map[string]interface{}
+	err := client.Run(context.Background(), NewRequest("query {}", srv.URL), &resp)
+	is.Equal(err.Error(), "decoding response: unexpected EOF")
+	last := logger.lines[len(logger.lines)-1]
+	is.True(strings.HasPrefix(last, "ERROR graphql decode failed"))
+	is.True(strings.Contains(last, `body {"data": {"items": ["item",`)) // body prefix is logged
+	is.True(len(last) < 2200)                                           // body prefix is bounded
+}
+
+func TestWithStreamingResponsesHTTPError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, strings.Repeat("x", 10000))
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithStreamingResponses())
+	err := client.Run(context.Background(), NewRequest("query {}", srv.URL), nil)
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusServiceUnavailable)
+	is.Equal(len(httpErr.Body), 2048)
+}
+
+func BenchmarkDecodeResponse(b *testing.B) {
+	var body bytes.Buffer
+	body.WriteString(`{"data":{"items":[`)
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `{"id":%d,"name":"item %d","description":"a reasonably long description of the item"}`, i, i)
+	}
+	body.WriteString(`]}}`)
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       ioutil.NopCloser(bytes.NewReader(body.Bytes())),
+			}, nil
+		}),
+	}
+	type item struct {
+		ID          int
+		Name        string
+		Description string
+	}
+	for _, bb := range []struct {
+		name   string
+		client *Client
+	}{
+		{name: "buffered", client: NewClient(WithHTTPClient(httpClient))},
+		{name: "streaming", client: NewClient(WithHTTPClient(httpClient), WithStreamingResponses())},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(body.Len()))
+			for i := 0; i < b.N; i++ {
+				var resp struct {
+					Items []item
+				}
+				if err := bb.client.Run(context.Background(), NewRequest("query { items }", "http://example.com/graphql"), &resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// DefaultSubscriptionProtocol is the WebSocket subprotocol used by
+// Subscribe unless changed with WithSubscriptionProtocol.
+const DefaultSubscriptionProtocol = "graphql-transport-ws"
+
+// WithSubscriptionProtocol sets the WebSocket subprotocol requested by
+// Subscribe.
+func WithSubscriptionProtocol(protocol string) ClientOption {
+	return func(client *Client) {
+		client.wsProtocol = protocol
+	}
+}
+
+// WithSubscriptionPingInterval makes subscriptions send a ping message
+// to the server at the given interval to keep the connection alive.
+// By default no pings are sent.
+func WithSubscriptionPingInterval(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.wsPingInterval = d
+	}
+}
+
+// wsMessage is a message of the graphql-transport-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// SubscriptionEvent is a single result delivered by a Subscription.
+type SubscriptionEvent struct {
+	Data   json.RawMessage
+	Errors []ErrorEntry
+
+	// decode unmarshals data with the decoder of the Client.
+	decode func(data []byte, v interface{}) error
+}
+
+// Decode unmarshals the data of the event into v, with the Decoder set
+// with WithJSONDecoder if any. If the event carries GraphQL errors, a
+// *GraphQLError is returned after v has been filled.
+func (e SubscriptionEvent) Decode(v interface{}) error {
+	if len(e.Data) > 0 && v != nil {
+		decode := e.decode
+		if decode == nil {
+			decode = json.Unmarshal
+		}
+		if err := decode(e.Data, v); err != nil {
+			return errors.Wrap(err, "decoding event")
+		}
+	}
+	if len(e.Errors) > 0 {
+		return &GraphQLError{Errors: e.Errors}
+	}
+	return nil
+}
+
+// Subscription is a running GraphQL subscription.
+type Subscription struct {
+	// C delivers the events of the subscription. It is closed when
+	// the subscription ends, after which Err reports why.
+	C <-chan SubscriptionEvent
+
+	c       chan SubscriptionEvent
+	client  *Client
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+	err     error
+
+	// untrack removes the subscription from its Client.
+	untrack func()
+}
+
+// Subscribe opens a WebSocket connection to the endpoint of req and
+// starts the subscription it describes, using the graphql-transport-ws
+// protocol. http and https endpoints are dialed as ws and wss.
+// The handshake request carries the headers and credentials configured
+// on the Client and passes through the WithBeforeRequest hooks, and
+// wss connections use the TLS configuration of its transport.
+// The subscription ends when ctx is cancelled, Close is called or the
+// server completes it.
+func (c *Client) Subscribe(ctx context.Context, req *Request) (*Subscription, error) {
+	req, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(req.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	protocol := c.wsProtocol
+	if protocol == "" {
+		protocol = DefaultSubscriptionProtocol
+	}
+	tlsConfig := c.tlsConfig
+	if req.httpClient != nil {
+		tlsConfig = tlsConfigOf(req.httpClient.Transport)
+	}
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		Subprotocols:     []string{protocol},
+		Jar:              c.httpClientFor(req).Jar,
+		TLSClientConfig:  tlsConfig,
+	}
+	// the handshake carries the same headers and credentials as any
+	// other request, and passes through the WithBeforeRequest hooks
+	r, err := http.NewRequest(http.MethodGet, req.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	r, err = c.prepareRequest(ctx, r, req.Header)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.beforeSend(ctx, r); err != nil {
+		return nil, err
+	}
+	c.logf(">> subscribe: %s", u)
+	conn, _, err := dialer.DialContext(ctx, u.String(), r.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial")
+	}
+	s := &Subscription{
+		c:      make(chan SubscriptionEvent),
+		client: c,
+		conn:   conn,
+		done:   make(chan struct{}),
+	}
+	s.C = s.c
+	if err := s.start(ctx, req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !c.track(s) {
+		conn.Close()
+		return nil, ErrClientClosed
+	}
+	go s.read()
+	go s.watch(ctx, c.wsPingInterval)
+	return s, nil
+}
+
+// tlsConfigOf returns a copy of the TLS configuration of the transport
+// rt for the WebSocket dialer, or nil if rt is not an *http.Transport.
+// HTTP/2 is not offered, as WebSocket connections use HTTP/1.1.
+func tlsConfigOf(rt http.RoundTripper) *tls.Config {
+	switch t := rt.(type) {
+	case nil:
+		return tlsConfigOf(http.DefaultTransport)
+	case *http.Transport:
+		if t.TLSClientConfig == nil {
+			return nil
+		}
+		config := t.TLSClientConfig.Clone()
+		config.NextProtos = nil
+		return config
+	case h2cTransport:
+		return tlsConfigOf(t.next)
+	}
+	return nil
+}
+
+// start performs the connection handshake and sends the subscribe
+// message.
+func (s *Subscription) start(ctx context.Context, req *Request) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetReadDeadline(deadline)
+		defer s.conn.SetReadDeadline(time.Time{})
+	}
+	if err := s.write(wsMessage{Type: "connection_init"}); err != nil {
+		return errors.Wrap(err, "connection init")
+	}
+	var msg wsMessage
+	if err := s.conn.ReadJSON(&msg); err != nil {
+		return errors.Wrap(err, "connection ack")
+	}
+	if msg.Type != "connection_ack" {
+		return errors.Errorf("graphql: expected connection_ack but got %s", msg.Type)
+	}
+	var payload bytes.Buffer
+	if err := s.client.newEncoder(&payload).Encode(newJSONBody(req)); err != nil {
+		return errors.Wrap(err, "encode body")
+	}
+	if err := s.write(wsMessage{ID: "1", Type: "subscribe", Payload: bytes.TrimSpace(payload.Bytes())}); err != nil {
+		return errors.Wrap(err, "subscribe")
+	}
+	return nil
+}
+
+// write sends msg encoded with the encoder of the Client.
+func (s *Subscription) write(msg wsMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	w, err := s.conn.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return err
+	}
+	if err := s.client.newEncoder(w).Encode(msg); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// read delivers incoming messages on C until the subscription ends.
+func (s *Subscription) read() {
+	defer close(s.c)
+	for {
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			select {
+			case <-s.done:
+			default:
+				s.err = errors.Wrap(err, "read message")
+				s.Close()
+			}
+			return
+		}
+		event := SubscriptionEvent{decode: s.client.decodeData}
+		switch msg.Type {
+		case "next":
+			var gr struct {
+				Data   json.RawMessage
+				Errors []ErrorEntry
+			}
+			if err := s.client.newDecoder(bytes.NewReader(msg.Payload)).Decode(&gr); err != nil {
+				s.err = errors.Wrap(err, "decoding event")
+				s.Close()
+				return
+			}
+			event.Data = gr.Data
+			event.Errors = gr.Errors
+		case "error":
+			if err := s.client.newDecoder(bytes.NewReader(msg.Payload)).Decode(&event.Errors); err != nil {
+				s.err = errors.Wrap(err, "decoding error")
+				s.Close()
+				return
+			}
+		case "complete":
+			s.Close()
+			return
+		case "ping":
+			s.write(wsMessage{Type: "pong"})
+			continue
+		default:
+			continue
+		}
+		select {
+		case s.c <- event:
+		case <-s.done:
+			return
+		}
+		if msg.Type == "error" {
+			s.Close()
+			return
+		}
+	}
+}
+
+// watch closes the subscription once ctx is done and sends pings at the
+// given interval, if any.
+func (s *Subscription) watch(ctx context.Context, pingInterval time.Duration) {
+	var ping <-chan time.Time
+	if pingInterval > 0 {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			s.Close()
+			return
+		case <-s.done:
+			return
+		case <-ping:
+			s.write(wsMessage{Type: "ping"})
+		}
+	}
+}
+
+// Close ends the subscription by sending a complete message and closing
+// the connection. It is safe to call Close more than once.
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		if s.untrack != nil {
+			s.untrack()
+		}
+		s.write(wsMessage{ID: "1", Type: "complete"})
+		s.writeMu.Lock()
+		s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// Err returns the error that ended the subscription, if any. It should
+// only be called after C has been closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
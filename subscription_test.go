@@ -0,0 +1,206 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/matryer/is"
+)
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	completed := make(chan struct{})
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{DefaultSubscriptionProtocol},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("X-Custom-Header"), "123")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		is.NoErr(err)
+		defer conn.Close()
+		is.Equal(conn.Subprotocol(), DefaultSubscriptionProtocol)
+
+		var msg wsMessage
+		is.NoErr(conn.ReadJSON(&msg))
+		is.Equal(msg.Type, "connection_init")
+		is.NoErr(conn.WriteJSON(wsMessage{Type: "connection_ack"}))
+		is.NoErr(conn.ReadJSON(&msg))
+		is.Equal(msg.Type, "subscribe")
+		is.Equal(string(msg.Payload), `{"query":"subscription { counter }","variables":{"from":1}}`)
+		id := msg.ID
+		for _, payload := range []string{`{"data":{"counter":1}}`, `{"data":{"counter":2}}`} {
+			is.NoErr(conn.WriteJSON(wsMessage{ID: id, Type: "next", Payload: []byte(payload)}))
+		}
+		is.NoErr(conn.ReadJSON(&msg))
+		is.Equal(msg.Type, "complete")
+		is.Equal(msg.ID, id)
+		close(completed)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := NewClient()
+	req := NewRequest("subscription { counter }", srv.URL)
+	req.Var("from", 1)
+	req.Header.Set("X-Custom-Header", "123")
+	sub, err := client.Subscribe(ctx, req)
+	is.NoErr(err)
+
+	for want := 1; want <= 2; want++ {
+		var resp struct {
+			Counter int
+		}
+		is.NoErr((<-sub.C).Decode(&resp))
+		is.Equal(resp.Counter, want)
+	}
+	cancel()
+	select {
+	case <-completed:
+	case <-time.After(time.Second):
+		t.Fatal("server did not receive complete")
+	}
+	for range sub.C {
+	}
+	is.NoErr(sub.Err())
+}
+
+func TestSubscribeError(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		is.NoErr(err)
+		defer conn.Close()
+		var msg wsMessage
+		is.NoErr(conn.ReadJSON(&msg))
+		is.NoErr(conn.WriteJSON(wsMessage{Type: "connection_ack"}))
+		is.NoErr(conn.ReadJSON(&msg))
+		is.NoErr(conn.WriteJSON(wsMessage{ID: msg.ID, Type: "error", Payload: []byte(`[{"message":"not allowed"}]`)}))
+		conn.ReadJSON(&msg)
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithSubscriptionPingInterval(10 * time.Millisecond))
+	sub, err := client.Subscribe(context.Background(), NewRequest("subscription { counter }", srv.URL))
+	is.NoErr(err)
+	event, ok := <-sub.C
+	is.True(ok)
+	err = event.Decode(nil)
+	is.Equal(err.Error(), "graphql: not allowed")
+	_, ok = <-sub.C
+	is.True(!ok)
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		is.NoErr(err)
+		defer conn.Close()
+		var msg wsMessage
+		is.NoErr(conn.ReadJSON(&msg))
+		is.NoErr(conn.WriteJSON(wsMessage{Type: "connection_ack"}))
+		for conn.ReadJSON(&msg) == nil {
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	sub, err := client.Subscribe(context.Background(), NewRequest("subscription { counter }", srv.URL))
+	is.NoErr(err)
+	is.NoErr(client.Close())
+	select {
+	case _, ok := <-sub.C:
+		is.True(!ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed")
+	}
+	_, err = client.Subscribe(context.Background(), NewRequest("subscription { counter }", srv.URL))
+	is.Equal(err, ErrClientClosed)
+}
+
+func TestSubscribeCredentials(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("Authorization"), "Bearer secret")
+		is.Equal(r.Header.Get("X-Request-ID"), "f3b1c2")
+		is.Equal(r.Header.Get("X-Signature"), "signed")
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		is.NoErr(err)
+		defer conn.Close()
+		var msg wsMessage
+		is.NoErr(conn.ReadJSON(&msg))
+		is.NoErr(conn.WriteJSON(wsMessage{Type: "connection_ack"}))
+		is.NoErr(conn.ReadJSON(&msg))
+		is.NoErr(conn.WriteJSON(wsMessage{ID: msg.ID, Type: "next", Payload: []byte(`{"data":{"counter":1}}`)}))
+		conn.ReadJSON(&msg)
+	}))
+	defer srv.Close()
+
+	type requestIDKey struct{}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), requestIDKey{}, "f3b1c2"), 1*time.Second)
+	defer cancel()
+	client := NewClient(
+		WithBearerToken("secret"),
+		WithContextHeader("X-Request-ID", requestIDKey{}),
+		WithBeforeRequest(func(ctx context.Context, r *http.Request) error {
+			r.Header.Set("X-Signature", "signed")
+			return nil
+		}),
+		WithInsecureSkipVerify(), // the self-signed certificate of srv
+	)
+	defer client.Close()
+	sub, err := client.Subscribe(ctx, NewRequest("subscription { counter }", srv.URL))
+	is.NoErr(err)
+	var resp struct {
+		Counter int
+	}
+	is.NoErr((<-sub.C).Decode(&resp))
+	is.Equal(resp.Counter, 1)
+}
+
+func TestSubscribeCodec(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		is.NoErr(err)
+		defer conn.Close()
+		var msg wsMessage
+		is.NoErr(conn.ReadJSON(&msg))
+		is.NoErr(conn.WriteJSON(wsMessage{Type: "connection_ack"}))
+		is.NoErr(conn.ReadJSON(&msg))
+		is.Equal(string(msg.Payload), `{"query":"subscription ($tag: String!) { counter(tag: $tag) }","variables":{"tag":"<b>"}}`) // not HTML escaped
+		is.NoErr(conn.WriteJSON(wsMessage{ID: msg.ID, Type: "next", Payload: []byte(`{"data":{"counter":9007199254740993}}`)}))
+		conn.ReadJSON(&msg)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient(
+		WithJSONEncoder(func(w io.Writer) Encoder {
+			enc := json.NewEncoder(w)
+			enc.SetEscapeHTML(false)
+			return enc
+		}),
+		WithJSONDecoder(func(r io.Reader) Decoder {
+			dec := json.NewDecoder(r)
+			dec.UseNumber()
+			return dec
+		}),
+	)
+	defer client.Close()
+	req := NewRequest("subscription ($tag: String!) { counter(tag: $tag) }", srv.URL)
+	req.Var("tag", "<b>")
+	sub, err := client.Subscribe(ctx, req)
+	is.NoErr(err)
+	var resp map[string]interface{}
+	is.NoErr((<-sub.C).Decode(&resp))
+	is.Equal(resp["counter"], json.Number("9007199254740993")) // decoded with UseNumber
+}
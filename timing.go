@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTimings breaks down the time spent on the HTTP request of a
+// call to Run. If the request was sent more than once, for example
+// because of retries, the timings are those of the last attempt.
+// The durations of phases that did not happen, such as the DNS lookup
+// of an IP address or the connection setup when a connection is reused,
+// are zero.
+type RequestTimings struct {
+	// OperationName is the name set with Request.OpName, or else with
+	// Request.OperationName, or else the name of the first named
+	// operation in the query. It is empty for anonymous operations.
+	OperationName string
+	// Endpoint is the endpoint of the request.
+	Endpoint string
+	// DNSLookup is the time spent resolving the host name.
+	DNSLookup time.Duration
+	// Connect is the time spent establishing the TCP connection.
+	Connect time.Duration
+	// TLSHandshake is the time spent on the TLS handshake.
+	TLSHandshake time.Duration
+	// TimeToFirstByte is the time from the start of the request until
+	// the first byte of the response, including the phases above.
+	TimeToFirstByte time.Duration
+	// ConnReused reports whether an idle connection was reused.
+	ConnReused bool
+}
+
+// WithClientTrace sets a function that is called once for every call
+// to Run with the timings of its HTTP request, collected with
+// net/http/httptrace, to tell whether latency comes from DNS, the
+// connection setup or the server. fn is not called if no request was
+// sent.
+func WithClientTrace(fn func(t RequestTimings)) ClientOption {
+	return func(client *Client) {
+		client.clientTrace = fn
+	}
+}
+
+// timingCollector records the timings of HTTP requests through an
+// httptrace.ClientTrace. Its hooks may be called from other goroutines.
+type timingCollector struct {
+	mu       sync.Mutex
+	sent     bool
+	timings  RequestTimings
+	start    time.Time
+	dnsStart time.Time
+	connect  time.Time
+	tlsStart time.Time
+}
+
+// withTrace returns ctx with a client trace that records timings in tc.
+func (tc *timingCollector) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			// a new attempt starts
+			tc.sent = true
+			tc.timings = RequestTimings{}
+			tc.start = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.timings.ConnReused = info.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.timings.DNSLookup = time.Since(tc.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.connect = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.timings.Connect = time.Since(tc.connect)
+		},
+		TLSHandshakeStart: func() {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.timings.TLSHandshake = time.Since(tc.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			tc.mu.Lock()
+			defer tc.mu.Unlock()
+			tc.timings.TimeToFirstByte = time.Since(tc.start)
+		},
+	})
+}
+
+// result returns the timings of the last request, and false if no
+// request was sent.
+func (tc *timingCollector) result() (RequestTimings, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.timings, tc.sent
+}
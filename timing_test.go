@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithClientTrace(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var timings []RequestTimings
+	client := NewClient(WithHTTPClient(srv.Client()), WithClientTrace(func(t RequestTimings) {
+		timings = append(timings, t)
+	}))
+	req := NewRequest("query {}", srv.URL)
+	req.OpName("Test")
+	is.NoErr(client.Run(ctx, req, nil))
+	is.NoErr(client.Run(ctx, req, nil))
+	is.Equal(len(timings), 2) // once per Run
+
+	first := timings[0]
+	is.Equal(first.OperationName, "Test")
+	is.Equal(first.Endpoint, srv.URL)
+	is.True(!first.ConnReused)
+	is.True(first.Connect > 0)
+	is.True(first.TLSHandshake > 0)
+	is.True(first.TimeToFirstByte >= 10*time.Millisecond)
+	is.True(first.TimeToFirstByte > first.Connect+first.TLSHandshake)
+
+	second := timings[1]
+	is.True(second.ConnReused)
+	is.Equal(second.Connect, time.Duration(0))
+	is.True(second.TimeToFirstByte >= 10*time.Millisecond)
+}
@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer creates spans around calls to Run. It is deliberately small so
+// that any tracing library can be adapted to it without this package
+// depending on one. With OpenTelemetry, an adapter looks like:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, graphql.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	func (t otelTracer) Inject(ctx context.Context, header http.Header) {
+//		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+//	}
+type Tracer interface {
+	// Start starts a span named name as a child of any span in ctx.
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject adds the trace context of the span in ctx to the headers
+	// of an outgoing request, such as the traceparent header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// WithTracer makes Run create a client span with t for every request.
+// The span is named after the operation name of the request,
+// records the endpoint, the HTTP status code and any error, and its
+// trace context is added to the headers of the request.
+func WithTracer(t Tracer) ClientOption {
+	return func(client *Client) {
+		client.tracer = t
+	}
+}
+
+// startSpan starts the span of a call to Run, if a Tracer is set.
+func (c *Client) startSpan(ctx context.Context, req *Request) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+	name := "graphql"
+	if label := req.label(); label != "" {
+		name += " " + label
+	}
+	ctx, span := c.tracer.Start(ctx, name)
+	span.SetAttribute("graphql.operation.name", req.label())
+	span.SetAttribute("http.url", c.endpointOf(req))
+	return ctx, span
+}
@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+type testSpanKey struct{}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{
+		name:       name,
+		traceID:    fmt.Sprintf("%032x", len(t.spans)+1),
+		attributes: make(map[string]interface{}),
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func (t *testTracer) Inject(ctx context.Context, header http.Header) {
+	if span, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		header.Set("traceparent", "00-"+span.traceID+"-0000000000000001-01")
+	}
+}
+
+type testSpan struct {
+	name       string
+	traceID    string
+	attributes map[string]interface{}
+	errs       []error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.errs = append(s.errs, err)
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+func TestWithTracer(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		is.Equal(r.Header.Get("traceparent"), "00-00000000000000000000000000000001-0000000000000001-01")
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `Internal Server Error`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	tracer := &testTracer{}
+	client := NewClient(WithTracer(tracer))
+	req := NewRequest("query {}", srv.URL)
+	req.OpName("GetValue")
+	err := client.Run(ctx, req, nil)
+	is.True(err != nil)
+	is.Equal(calls, 1)
+	is.Equal(len(tracer.spans), 1)
+	span := tracer.spans[0]
+	is.Equal(span.name, "graphql GetValue")
+	is.Equal(span.attributes["graphql.operation.name"], "GetValue")
+	is.Equal(span.attributes["http.url"], srv.URL)
+	is.Equal(span.attributes["http.status_code"], http.StatusInternalServerError)
+	is.Equal(span.errs, []error{err})
+	is.True(span.ended)
+}
@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// WithTransport adds middleware around the transport of the http.Client
+// used by the Client, which is http.DefaultTransport unless another one
+// is set with WithHTTPClient. When WithTransport is used more than once,
+// requests pass through the middleware in the order it was added.
+// The http.Client given to WithHTTPClient is copied, not modified.
+//
+//	NewClient(WithTransport(func(next http.RoundTripper) http.RoundTripper {
+//		return tracingTransport{next}
+//	}))
+func WithTransport(wrap func(http.RoundTripper) http.RoundTripper) ClientOption {
+	return func(client *Client) {
+		client.transports = append(client.transports, wrap)
+	}
+}
+
+// wrapTransport returns a copy of httpClient with its transport wrapped
+// in the middleware added with WithTransport.
+func (c *Client) wrapTransport(httpClient *http.Client) *http.Client {
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.transports) - 1; i >= 0; i-- {
+		transport = c.transports[i](transport)
+	}
+	wrapped := *httpClient
+	wrapped.Transport = transport
+	return &wrapped
+}
+
+// connectionPool holds the limits set with WithConnectionPool.
+type connectionPool struct {
+	maxIdle, maxIdlePerHost, maxConnsPerHost int
+}
+
+// WithConnectionPool sets the connection limits of the transport used by
+// the Client, whose defaults allow only two idle connections per host
+// and become a bottleneck under high concurrency. maxIdle and
+// maxIdlePerHost limit the idle connections kept for reuse in total and
+// per host, and maxConnsPerHost limits the connections per host,
+// including those in use; zero means no limit for maxIdle and
+// maxConnsPerHost.
+// The transport of the http.Client given to WithHTTPClient is cloned
+// and tuned if it is an *http.Transport, and left as is otherwise.
+func WithConnectionPool(maxIdle, maxIdlePerHost, maxConnsPerHost int) ClientOption {
+	return func(client *Client) {
+		client.pool = &connectionPool{
+			maxIdle:         maxIdle,
+			maxIdlePerHost:  maxIdlePerHost,
+			maxConnsPerHost: maxConnsPerHost,
+		}
+	}
+}
+
+// withPool returns a copy of httpClient with a transport tuned to the
+// limits set with WithConnectionPool, or httpClient itself if its
+// transport is not an *http.Transport.
+func (c *Client) withPool(httpClient *http.Client) *http.Client {
+	return tuneTransport(httpClient, func(t *http.Transport) {
+		t.MaxIdleConns = c.pool.maxIdle
+		t.MaxIdleConnsPerHost = c.pool.maxIdlePerHost
+		t.MaxConnsPerHost = c.pool.maxConnsPerHost
+	})
+}
+
+// DisableKeepAlives makes the Client close the connection after every
+// request instead of keeping it open for reuse, so that short-lived
+// programs such as command line tools and tests do not hold on to idle
+// connections.
+// The transport of the http.Client given to WithHTTPClient is cloned
+// and changed if it is an *http.Transport, and left as is otherwise.
+func DisableKeepAlives() ClientOption {
+	return func(client *Client) {
+		client.disableKeepAlives = true
+	}
+}
+
+// WithInsecureSkipVerify makes the Client accept any TLS certificate,
+// such as the self-signed certificate of a development server.
+//
+// WARNING: this disables the verification that the server is the one
+// it claims to be, so anyone on the network path can read and change
+// requests, including credentials, and responses. Never use it in
+// production.
+//
+// The option has no effect if an http.Client is set with
+// WithHTTPClient; configure its transport instead. The default
+// transport is cloned, not modified.
+func WithInsecureSkipVerify() ClientOption {
+	return func(client *Client) {
+		client.insecureSkipVerify = true
+	}
+}
+
+// skipVerify returns a copy of httpClient with a transport that does
+// not verify TLS certificates, see WithInsecureSkipVerify.
+func skipVerify(httpClient *http.Client) *http.Client {
+	return tuneTransport(httpClient, func(t *http.Transport) {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		} else {
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true
+	})
+}
+
+// tuneTransport returns a copy of httpClient with a clone of its
+// transport changed by tune, or httpClient itself if its transport is
+// not an *http.Transport.
+func tuneTransport(httpClient *http.Client, tune func(*http.Transport)) *http.Client {
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return httpClient
+	}
+	t = t.Clone()
+	tune(t)
+	tuned := *httpClient
+	tuned.Transport = t
+	return &tuned
+}
@@ -0,0 +1,146 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithTransport(t *testing.T) {
+	is := is.New(t)
+	var order []string
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data":{}}`)),
+		}, nil
+	})
+	middleware := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	httpClient := &http.Client{Transport: base}
+	client := NewClient(
+		WithTransport(middleware("first")),
+		WithHTTPClient(httpClient),
+		WithTransport(middleware("second")),
+	)
+	for i := 0; i < 2; i++ {
+		err := client.Run(context.Background(), NewRequest("query {}", "http://example.com/graphql"), nil)
+		is.NoErr(err)
+	}
+	is.Equal(order, []string{"first", "second", "base", "first", "second", "base"})
+	is.Equal(httpClient.Transport, base) // the http.Client is not modified
+}
+
+func TestWithConnectionPool(t *testing.T) {
+	is := is.New(t)
+	httpClient := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 1}}
+	client := NewClient(WithHTTPClient(httpClient), WithConnectionPool(200, 100, 50))
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	is.True(ok)
+	is.Equal(transport.MaxIdleConns, 200)
+	is.Equal(transport.MaxIdleConnsPerHost, 100)
+	is.Equal(transport.MaxConnsPerHost, 50)
+	is.Equal(httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost, 1) // not modified
+
+	client = NewClient(WithConnectionPool(200, 100, 50))
+	is.Equal(client.httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost, 100)
+	is.Equal(http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost, 0) // not modified
+
+	custom := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	client = NewClient(WithHTTPClient(&http.Client{Transport: custom}), WithConnectionPool(200, 100, 50))
+	_, ok = client.httpClient.Transport.(roundTripperFunc)
+	is.True(ok) // other transports are left as is
+}
+
+func BenchmarkConnectionPool(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"value":"some data"}}`)
+	}))
+	defer srv.Close()
+	newHTTPClient := func() *http.Client {
+		return &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+	for _, bb := range []struct {
+		name string
+		opts []ClientOption
+	}{
+		{name: "default", opts: []ClientOption{WithHTTPClient(newHTTPClient())}},
+		{name: "pool", opts: []ClientOption{WithHTTPClient(newHTTPClient()), WithConnectionPool(100, 100, 0)}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			client := NewClient(bb.opts...)
+			defer client.Close()
+			b.SetParallelism(16)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					var resp struct {
+						Value string
+					}
+					if err := client.Run(context.Background(), NewRequest("query { value }", srv.URL), &resp); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestDisableKeepAlives(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.True(r.Close) // Connection: close
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	httpClient := &http.Client{Transport: &http.Transport{}}
+	client := NewClient(WithHTTPClient(httpClient), DisableKeepAlives())
+	is.NoErr(client.Run(context.Background(), NewRequest("query {}", srv.URL), nil))
+	is.True(client.httpClient.Transport.(*http.Transport).DisableKeepAlives)
+	is.True(!httpClient.Transport.(*http.Transport).DisableKeepAlives) // not modified
+
+	client = NewClient(DisableKeepAlives(), WithConnectionPool(200, 100, 50))
+	transport := client.httpClient.Transport.(*http.Transport)
+	is.True(transport.DisableKeepAlives)
+	is.Equal(transport.MaxIdleConns, 200)
+	is.True(!http.DefaultTransport.(*http.Transport).DisableKeepAlives) // not modified
+}
+
+func TestWithInsecureSkipVerify(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	err := NewClient().Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.True(IsNetworkError(err)) // the self-signed certificate is rejected
+
+	is.NoErr(NewClient(WithInsecureSkipVerify()).Run(ctx, NewRequest("query {}", srv.URL), nil))
+	if tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig; tlsConfig != nil {
+		is.True(!tlsConfig.InsecureSkipVerify) // the default transport is not modified
+	}
+
+	httpClient := &http.Client{Transport: &http.Transport{}}
+	err = NewClient(WithHTTPClient(httpClient), WithInsecureSkipVerify()).Run(ctx, NewRequest("query {}", srv.URL), nil)
+	is.True(IsNetworkError(err)) // ignored with a client of its own
+}
@@ -0,0 +1,20 @@
+package graphql
+
+import "context"
+
+// RunTyped executes req with r, usually a *Client, and returns the data
+// of the response unmarshaled into a T:
+//
+//	type userData struct {
+//	    User struct{ Name string }
+//	}
+//	data, err := graphql.RunTyped[userData](ctx, client, req)
+//
+// Errors are those of Run. When the response carries GraphQL errors
+// alongside data, the data is returned together with the
+// *GraphQLError.
+func RunTyped[T any](ctx context.Context, r Runner, req *Request) (T, error) {
+	var resp T
+	err := r.Run(ctx, req, &resp)
+	return resp, err
+}
@@ -0,0 +1,52 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunTyped(t *testing.T) {
+	is := is.New(t)
+	var status int
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	client := NewClient()
+
+	type userData struct {
+		User struct {
+			Name string
+		}
+	}
+	status, body = http.StatusOK, `{"data":{"user":{"name":"Mat"}}}`
+	data, err := RunTyped[userData](ctx, client, NewRequest("query {}", srv.URL))
+	is.NoErr(err)
+	is.Equal(data.User.Name, "Mat")
+
+	m, err := RunTyped[map[string]interface{}](ctx, client, NewRequest("query {}", srv.URL))
+	is.NoErr(err)
+	is.Equal(m, map[string]interface{}{"user": map[string]interface{}{"name": "Mat"}})
+
+	status, body = http.StatusOK, `{"data":{"user":{"name":"Mat"}},"errors":[{"message":"partial"}]}`
+	data, err = RunTyped[userData](ctx, client, NewRequest("query {}", srv.URL))
+	is.True(IsGraphQLError(err))
+	is.Equal(data.User.Name, "Mat") // partial data is returned with the error
+
+	status, body = http.StatusServiceUnavailable, `unavailable`
+	_, err = RunTyped[userData](ctx, client, NewRequest("query {}", srv.URL))
+	var httpErr *HTTPError
+	is.True(errors.As(err, &httpErr))
+	is.Equal(httpErr.StatusCode, http.StatusServiceUnavailable)
+}
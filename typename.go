@@ -0,0 +1,98 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExpectTypename makes Run check that the object at path in the data of
+// the response has one of the given types, and return a *TypenameError
+// otherwise. path is a dot-separated list of the field names, or
+// aliases, leading to the object, such as "node" or "search.results";
+// lists along the path are checked element by element. The query must
+// request the __typename field of the object. Null and missing objects
+// are not checked.
+// This catches responses of an unexpected member of an interface or
+// union early, instead of silently decoding them into the wrong type:
+//
+//	req := graphql.NewRequest(`query ($id: ID!) { node(id: $id) { __typename ... on User { name } } }`, "")
+//	req.ExpectTypename("node", "User")
+func (req *Request) ExpectTypename(path string, typenames ...string) {
+	if req.typenames == nil {
+		req.typenames = make(map[string][]string)
+	}
+	req.typenames[path] = append(req.typenames[path], typenames...)
+}
+
+// TypenameError is returned by Run when an object in the response does
+// not have the type expected with Request.ExpectTypename. The data of
+// the response has been unmarshaled into the response object.
+type TypenameError struct {
+	// Path is the path of the object as given to ExpectTypename.
+	Path string
+	// Typename is the __typename of the object, or an empty string if
+	// the object has none.
+	Typename string
+	// Expected holds the type names given to ExpectTypename.
+	Expected []string
+}
+
+func (e *TypenameError) Error() string {
+	expected := make([]string, len(e.Expected))
+	for i := range e.Expected {
+		expected[i] = fmt.Sprintf("%q", e.Expected[i])
+	}
+	if e.Typename == "" {
+		return fmt.Sprintf("graphql: %s has no __typename, expected %s", e.Path, strings.Join(expected, " or "))
+	}
+	return fmt.Sprintf("graphql: %s has __typename %q, expected %s", e.Path, e.Typename, strings.Join(expected, " or "))
+}
+
+// checkTypenames checks the objects of data against the types expected
+// with ExpectTypename.
+func (req *Request) checkTypenames(data []byte) error {
+	if len(req.typenames) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return &DecodeError{Err: err}
+	}
+	paths := make([]string, 0, len(req.typenames))
+	for path := range req.typenames {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := checkTypename(v, strings.Split(path, "."), path, req.typenames[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTypename checks the objects at path in v.
+func checkTypename(v interface{}, path []string, fullPath string, typenames []string) error {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, elem := range value {
+			if err := checkTypename(elem, path, fullPath, typenames); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if len(path) > 0 {
+			return checkTypename(value[path[0]], path[1:], fullPath, typenames)
+		}
+		typename, _ := value["__typename"].(string)
+		for _, expected := range typenames {
+			if typename == expected {
+				return nil
+			}
+		}
+		return &TypenameError{Path: fullPath, Typename: typename, Expected: typenames}
+	}
+	return nil
+}
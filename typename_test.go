@@ -0,0 +1,59 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestExpectTypename(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{
+			"node":{"__typename":"Post","title":"Hello"},
+			"search":{"results":[{"__typename":"User"},{"__typename":"Post"}]},
+			"viewer":{"login":"matryer"},
+			"missing":null
+		}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, opts := range [][]ClientOption{nil, {WithStreamingResponses()}} {
+		client := NewClient(opts...)
+		for _, tt := range []struct {
+			path      string
+			typenames []string
+			err       string
+		}{
+			{path: "node", typenames: []string{"Post"}},
+			{path: "node", typenames: []string{"User"}, err: `graphql: node has __typename "Post", expected "User"`},
+			{path: "search.results", typenames: []string{"User", "Post"}},
+			{path: "search.results", typenames: []string{"User"}, err: `graphql: search.results has __typename "Post", expected "User"`},
+			{path: "viewer", typenames: []string{"User"}, err: `graphql: viewer has no __typename, expected "User"`},
+			{path: "missing", typenames: []string{"User"}},
+		} {
+			req := NewRequest("query { node { __typename } }", srv.URL)
+			req.ExpectTypename(tt.path, tt.typenames...)
+			var resp struct {
+				Node struct{ Title string }
+			}
+			err := client.Run(ctx, req, &resp)
+			is.Equal(resp.Node.Title, "Hello") // the data is unmarshaled
+			if tt.err == "" {
+				is.NoErr(err)
+				continue
+			}
+			var typenameErr *TypenameError
+			is.True(errors.As(err, &typenameErr))
+			is.Equal(err.Error(), tt.err)
+		}
+	}
+}
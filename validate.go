@@ -0,0 +1,71 @@
+package graphql
+
+import "github.com/pkg/errors"
+
+// WithRequestValidator adds a function that checks every request before
+// it is sent, for example to enforce a limit on the depth of queries.
+// If validate returns an error, Run returns it without sending the
+// request. Validators run in the order they were added, and stop at the
+// first error.
+//
+//	NewClient(WithRequestValidator(func(req *graphql.Request) error {
+//	    if depth(req.Query()) > 10 {
+//	        return errors.New("query is too deep")
+//	    }
+//	    return nil
+//	}))
+func WithRequestValidator(validate func(*Request) error) ClientOption {
+	return func(client *Client) {
+		client.validators = append(client.validators, validate)
+	}
+}
+
+// ReadOnly makes the Client reject mutations and subscriptions, as a
+// safety rail for code that must only read data, such as code using a
+// read replica. The type of the operation is read from the query,
+// skipping leading whitespace, comments and fragments; anonymous
+// { ... } operations are queries. Run and Subscribe return an error for
+// other operations, and for operations whose type cannot be determined,
+// without sending them.
+func ReadOnly() ClientOption {
+	return func(client *Client) {
+		client.readOnly = true
+	}
+}
+
+// WithOperationAllowlist makes the Client reject queries whose hash is
+// not in hashes, so that a locked-down client can only send a known set
+// of operations. Queries are hashed like persisted queries: the hex
+// encoded SHA-256 hash by default, or the hash set with
+// WithPersistedQueryHash. Run returns an error for other queries
+// without sending them.
+func WithOperationAllowlist(hashes map[string]bool) ClientOption {
+	return func(client *Client) {
+		client.allowlist = hashes
+	}
+}
+
+// validate checks req against the ReadOnly and WithOperationAllowlist
+// options and runs the validators added with WithRequestValidator on it.
+func (c *Client) validate(req *Request) error {
+	if c.readOnly {
+		switch typ := selectedOperationType(req.q, req.operationName); typ {
+		case "query":
+		case "mutation", "subscription":
+			return errors.Errorf("graphql: cannot run a %s with a read-only client", typ)
+		default:
+			return errors.New("graphql: cannot determine the operation type for a read-only client")
+		}
+	}
+	if c.allowlist != nil {
+		if hash := c.queryHash(req.q); !c.allowlist[hash] {
+			return errors.Errorf("graphql: operation %s is not allowed", hash)
+		}
+	}
+	for _, validate := range c.validators {
+		if err := validate(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
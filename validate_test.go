@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWithRequestValidator(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	errTooDeep := errors.New("query is too deep")
+	var validated []string
+	client := NewClient(
+		WithRequestValidator(func(req *Request) error {
+			validated = append(validated, "depth")
+			if strings.Count(req.Query(), "{") > 2 {
+				return errTooDeep
+			}
+			return nil
+		}),
+		WithRequestValidator(func(req *Request) error {
+			validated = append(validated, "endpoint")
+			is.Equal(req.Endpoint, srv.URL)
+			return nil
+		}),
+	)
+	err := client.Run(context.Background(), NewRequest("query { a { b { c } } }", srv.URL), nil)
+	is.Equal(err, errTooDeep)
+	is.Equal(calls, 0)                     // no request is sent
+	is.Equal(validated, []string{"depth"}) // later validators are skipped
+
+	validated = nil
+	err = client.Run(context.Background(), NewRequest("query { a }", srv.URL), nil)
+	is.True(err != nil) // the test server returns no data
+	is.Equal(calls, 1)
+	is.Equal(validated, []string{"depth", "endpoint"})
+}
+
+func TestReadOnly(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(ReadOnly())
+	for q, want := range map[string]string{
+		"query { a }":                     "",
+		"{ a }":                           "",
+		"# list items\n  query { a }":     "",
+		"# save\nmutation { a }":          "graphql: cannot run a mutation with a read-only client",
+		"  \n\tsubscription { a }":        "graphql: cannot run a subscription with a read-only client",
+		"# { a }\n\ufeffmutation M { a }": "graphql: cannot run a mutation with a read-only client",
+	} {
+		err := client.Run(context.Background(), NewRequest(q, srv.URL), nil)
+		if want == "" {
+			is.NoErr(err) // query
+			continue
+		}
+		is.Equal(err.Error(), want) // mutation
+	}
+	is.Equal(calls, 3)
+
+	req := NewRequest("query A { a } mutation B { b }", srv.URL)
+	req.OperationName("B")
+	err := client.Run(context.Background(), req, nil)
+	is.Equal(err.Error(), "graphql: cannot run a mutation with a read-only client")
+	is.Equal(calls, 3)
+
+	req = NewRequest("fragment F on User { id } mutation M { deleteAll { ...F } }", srv.URL)
+	err = client.Run(context.Background(), req, nil)
+	is.Equal(err.Error(), "graphql: cannot run a mutation with a read-only client")
+	is.Equal(calls, 3) // the fragment does not hide the mutation
+
+	err = client.Run(context.Background(), NewRequest("fragment F on User { id }", srv.URL), nil)
+	is.Equal(err.Error(), "graphql: cannot determine the operation type for a read-only client")
+	is.Equal(calls, 3)
+}
+
+func TestWithOperationAllowlist(t *testing.T) {
+	is := is.New(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithOperationAllowlist(map[string]bool{
+		// SHA-256 of "query { a }"
+		"ddd02bbac723794979bbd96cae50ff8cfc4e817bfef93a47055d122626b440ec": true,
+	}))
+	is.NoErr(client.Run(context.Background(), NewRequest("query { a }", srv.URL), nil))
+	is.Equal(calls, 1)
+
+	err := client.Run(context.Background(), NewRequest("query { b }", srv.URL), nil)
+	is.Equal(err.Error(), "graphql: operation 115b9db731bea59e8540c6f4924fe7317fcf723411611027811191fdd9ff4064 is not allowed")
+	is.Equal(calls, 1) // the unlisted query is not sent
+}
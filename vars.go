@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// VarID sets a variable of the ID type. GraphQL IDs are serialized as
+// strings, so integers and other values are converted to their decimal
+// or fmt.Sprint representation.
+func (req *Request) VarID(key string, id interface{}) {
+	switch id := id.(type) {
+	case string:
+		req.Var(key, id)
+	case fmt.Stringer:
+		req.Var(key, id.String())
+	default:
+		req.Var(key, fmt.Sprint(id))
+	}
+}
+
+// VarEnum sets a variable of an enum type to the name of the enum
+// value, such as "ADMIN". Enum values are sent as JSON strings in
+// variables, and the server maps them to the enum.
+func (req *Request) VarEnum(key string, value string) {
+	req.Var(key, value)
+}
+
+// VarTime sets a variable to t formatted with layout, or with
+// time.RFC3339 if layout is empty, instead of the default JSON encoding
+// of time.Time.
+func (req *Request) VarTime(key string, t time.Time, layout string) {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	req.Var(key, t.Format(layout))
+}
+
+// VariablesJSON returns the variables object of req exactly as Run
+// sends it in the body of a JSON request, encoded with the encoder of
+// the Client and the options that apply to it, such as
+// WithCanonicalJSON, for inspecting requests in tests and logs.
+// Variables set with VarFunc are resolved, and variables marked with
+// RedactVar are included as they are.
+func (c *Client) VariablesJSON(req *Request) ([]byte, error) {
+	resolved, err := req.resolveVarFuncs()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := c.newEncoder(&buf).Encode(newJSONBody(resolved)); err != nil {
+		return nil, errors.Wrap(err, "encode body")
+	}
+	var body struct {
+		Variables json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &body); err != nil {
+		return nil, errors.Wrap(err, "decode body")
+	}
+	return body.Variables, nil
+}
@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type userID int
+
+func (id userID) String() string {
+	return fmt.Sprintf("user-%d", int(id))
+}
+
+func TestTypedVars(t *testing.T) {
+	is := is.New(t)
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	req := NewRequest("query {}", "")
+	req.VarID("id", "abc")
+	req.VarID("intID", 42)
+	req.VarID("bigID", int64(9007199254740993))
+	req.VarID("stringerID", userID(7))
+	req.VarEnum("role", "ADMIN")
+	req.VarTime("since", tm, "")
+	req.VarTime("day", tm, "2006-01-02")
+
+	b, err := json.Marshal(req.Vars())
+	is.NoErr(err)
+	is.Equal(string(b), `{"bigID":"9007199254740993","day":"2020-01-02","id":"abc","intID":"42","role":"ADMIN","since":"2020-01-02T03:04:05+01:00","stringerID":"user-7"}`)
+}
+
+func TestBytesVars(t *testing.T) {
+	is := is.New(t)
+	const want = `{"data":"aGVsbG8=","nested":{"list":["AAE="],"value":"d29ybGQ="}}`
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			got = append(got, r.URL.Query().Get("variables"))
+		case strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/"):
+			got = append(got, strings.TrimSpace(r.FormValue("variables")))
+		default:
+			var body struct {
+				Variables json.RawMessage
+			}
+			is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+			got = append(got, string(body.Variables))
+		}
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, client := range []*Client{
+		NewClient(),
+		NewClient(UseGETForQueries()),
+		NewClient(UseMultipartForm()),
+		NewClient(WithCanonicalJSON()),
+	} {
+		req := NewRequest("query ($data: String!) { echo(data: $data) }", srv.URL)
+		req.Var("data", []byte("hello"))
+		req.Var("nested", map[string]interface{}{
+			"value": []byte("world"),
+			"list":  []interface{}{[]byte{0, 1}},
+		})
+		is.NoErr(client.Run(ctx, req, nil))
+	}
+	is.Equal(got, []string{want, want, want, want}) // base64 strings at any depth
+}
+
+func TestVariablesJSON(t *testing.T) {
+	is := is.New(t)
+	var sent json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables json.RawMessage
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&body))
+		sent = body.Variables
+		io.WriteString(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	req := NewRequest("query ($id: ID!, $password: String, $since: Int) {}", srv.URL)
+	req.VarID("id", 42)
+	req.Var("password", "hunter2")
+	req.RedactVar("password")
+	req.VarFunc("since", func() (interface{}, error) {
+		return 1577934245, nil
+	})
+	req.Var("filter", struct {
+		Tags   []string `json:"tags"`
+		Active bool     `json:"active"`
+	}{Tags: []string{"b", "a"}, Active: true})
+	b, err := NewClient().VariablesJSON(req)
+	is.NoErr(err)
+	is.Equal(string(b), `{"filter":{"tags":["b","a"],"active":true},"id":"42","password":"hunter2","since":1577934245}`)
+
+	for _, client := range []*Client{
+		NewClient(),
+		NewClient(WithCanonicalJSON()),
+		NewClient(WithCompactJSON(false)),
+		NewClient(WithJSONEncoder(func(w io.Writer) Encoder {
+			enc := json.NewEncoder(w)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "\t")
+			return enc
+		})),
+	} {
+		b, err := client.VariablesJSON(req)
+		is.NoErr(err)
+		is.NoErr(client.Run(ctx, req, nil))
+		is.Equal(string(b), string(sent)) // the same bytes as Run sends
+	}
+}